@@ -1,14 +1,20 @@
 package main
 
 import (
+	"TestHitalent/internal/auth"
 	"TestHitalent/internal/config"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/repository"
 	"TestHitalent/pkg/logger"
 	"TestHitalent/pkg/postgres"
+	"TestHitalent/pkg/sqlite"
 	"context"
+	"fmt"
 	"os"
 
 	"github.com/pressly/goose/v3"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -24,7 +30,7 @@ func main() {
 		panic(err)
 	}
 
-	db, err := postgres.New(cfg.Postgres)
+	db, dialect, dir, err := openDatabase(cfg)
 	if err != nil {
 		panic(err)
 	}
@@ -35,7 +41,7 @@ func main() {
 		panic(err)
 	}
 
-	if err := goose.SetDialect("postgres"); err != nil {
+	if err := goose.SetDialect(dialect); err != nil {
 		logger.GetLoggerFromCtx(ctx).Error("Failed to set goose dialect", zap.Error(err))
 		panic(err)
 	}
@@ -49,34 +55,79 @@ func main() {
 
 	switch command {
 	case "up":
-		if err := goose.Up(sqlDB, "migrations"); err != nil {
+		if err := goose.Up(sqlDB, dir); err != nil {
 			logger.GetLoggerFromCtx(ctx).Error("Migration up failed", zap.Error(err))
 			panic(err)
 		}
 		logger.GetLoggerFromCtx(ctx).Info("Migrations applied successfully!")
 
 	case "down":
-		if err := goose.Down(sqlDB, "migrations"); err != nil {
+		if err := goose.Down(sqlDB, dir); err != nil {
 			logger.GetLoggerFromCtx(ctx).Error("Migration down failed", zap.Error(err))
 			panic(err)
 		}
 		logger.GetLoggerFromCtx(ctx).Info("Last migration rolled back!")
 
 	case "reset":
-		if err := goose.Reset(sqlDB, "migrations"); err != nil {
+		if err := goose.Reset(sqlDB, dir); err != nil {
 			logger.GetLoggerFromCtx(ctx).Error("Migration reset failed", zap.Error(err))
 			panic(err)
 		}
 		logger.GetLoggerFromCtx(ctx).Info("All migrations rolled back!")
 
 	case "status":
-		if err := goose.Status(sqlDB, "migrations"); err != nil {
+		if err := goose.Status(sqlDB, dir); err != nil {
 			logger.GetLoggerFromCtx(ctx).Error("Migration status failed", zap.Error(err))
 			panic(err)
 		}
 
+	case "create-api-key":
+		if len(os.Args) < 3 {
+			logger.GetLoggerFromCtx(ctx).Error("Usage: create-api-key <name>")
+			os.Exit(1)
+		}
+		key, err := auth.GenerateAPIKey()
+		if err != nil {
+			logger.GetLoggerFromCtx(ctx).Error("Failed to generate API key", zap.Error(err))
+			panic(err)
+		}
+		repo := repository.NewHiTalentRepository(db)
+		if _, err := repo.CreateAPIKey(ctx, &models.APIKey{Name: os.Args[2], KeyHash: auth.HashAPIKey(key)}); err != nil {
+			logger.GetLoggerFromCtx(ctx).Error("Failed to store API key", zap.Error(err))
+			panic(err)
+		}
+		logger.GetLoggerFromCtx(ctx).Info("API key created, it is shown only once", zap.String("name", os.Args[2]))
+		fmt.Println(key)
+
+	case "revoke-api-key":
+		if len(os.Args) < 3 {
+			logger.GetLoggerFromCtx(ctx).Error("Usage: revoke-api-key <name>")
+			os.Exit(1)
+		}
+		repo := repository.NewHiTalentRepository(db)
+		if err := repo.RevokeAPIKey(ctx, os.Args[2]); err != nil {
+			logger.GetLoggerFromCtx(ctx).Error("Failed to revoke API key", zap.Error(err))
+			os.Exit(1)
+		}
+		logger.GetLoggerFromCtx(ctx).Info("API key revoked", zap.String("name", os.Args[2]))
+
 	default:
 		logger.GetLoggerFromCtx(ctx).Error("Unknown command", zap.String("command", command))
 		os.Exit(1)
 	}
 }
+
+// openDatabase opens the database chats are kept in, with the goose dialect
+// and migrations directory that match it.
+func openDatabase(cfg *config.Config) (*gorm.DB, string, string, error) {
+	switch cfg.Storage {
+	case config.StoragePostgres:
+		db, err := postgres.New(cfg.Postgres)
+		return db, "postgres", "migrations", err
+	case config.StorageSQLite:
+		db, err := sqlite.New(cfg.SQLite)
+		return db, "sqlite3", "migrations/sqlite", err
+	default:
+		return nil, "", "", fmt.Errorf("storage %q has no database to migrate", cfg.Storage)
+	}
+}
@@ -1,49 +1,101 @@
 package app
 
 import (
+	"TestHitalent/internal/auth"
 	"TestHitalent/internal/config"
-	"TestHitalent/internal/repository"
+	"TestHitalent/internal/events"
+	"TestHitalent/internal/metrics"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/ratelimit"
 	"TestHitalent/internal/service"
 	"TestHitalent/internal/transport"
 	"TestHitalent/pkg/logger"
-	"TestHitalent/pkg/postgres"
+	"TestHitalent/pkg/tracing"
 	"context"
-	"os"
+	"database/sql"
+	"errors"
+	"fmt"
 	"os/signal"
 	"sync"
 	"syscall"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pressly/goose/v3"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// eventBufferSize is how many events a slow stream subscriber may lag
+// behind before it is disconnected.
+const eventBufferSize = 64
+
+// migrationsDir and sqliteMigrationsDir hold the goose migrations applied at
+// startup to Postgres and SQLite.
+const (
+	migrationsDir       = "migrations"
+	sqliteMigrationsDir = "migrations/sqlite"
+)
+
 type App struct {
 	HiTalentServer *transport.HiTalentServer
-	cfg                *config.Config
-	ctx                context.Context
-	wg                 sync.WaitGroup
-	cancel             context.CancelFunc
+	listener       *events.Listener
+	cfg            *config.Config
+	ctx            context.Context
+	wg             sync.WaitGroup
+	cancel         context.CancelFunc
+	db             *sql.DB
+	pool           *pgxpool.Pool
+	tracerProvider *sdktrace.TracerProvider
 }
 
-func NewApp(cfg *config.Config,context context.Context) *App {
-	db,err := postgres.New(cfg.Postgres)
+func NewApp(cfg *config.Config, ctx context.Context) *App {
+	verifier, err := auth.NewJWTVerifier(cfg.Auth)
+	if err != nil {
+		panic(err)
+	}
+
+	tp, err := tracing.New(ctx, cfg.Tracing)
 	if err != nil {
 		panic(err)
 	}
 
-	// Run migrations
-	if err := runMigrations(db, context); err != nil {
+	m := metrics.New()
+	broker := events.NewBroker(eventBufferSize)
+
+	store, err := newStorage(ctx, cfg, tp, m, broker)
+	if err != nil {
 		panic(err)
 	}
 
-	repo := repository.NewHiTalentRepository(db, context)
-	srv := service.NewHiTalentService(context,repo)
-	server := transport.NewHiTalentServer(cfg, srv,context)
+	opts := []transport.Option{
+		transport.WithMetrics(m),
+		transport.WithTracing(tp),
+		transport.WithReadinessChecks(store.checks...),
+		transport.WithIdempotency(store.idempotency),
+	}
+	if cfg.RateLimit.Enabled {
+		limiter, err := newRateLimiter(cfg.RateLimit, store.postgres)
+		if err != nil {
+			panic(err)
+		}
+		opts = append(opts, transport.WithRateLimit(limiter, cfg.RateLimit))
+	}
+
+	srv := service.NewHiTalentService(metrics.NewRepository(store.repo, m), broker, verifier)
+	server := transport.NewHiTalentServer(cfg, service.NewTracedService(srv, tp), ctx, opts...)
+
+	// Background work stops with the app; requests carry their own contexts.
+	appCtx, cancel := context.WithCancel(ctx)
 	return &App{
 		HiTalentServer: server,
+		listener:       store.listener,
 		cfg:            cfg,
-		ctx:            context,
+		ctx:            appCtx,
+		cancel:         cancel,
+		db:             store.db,
+		pool:           store.pool,
+		tracerProvider: tp,
 	}
 }
 
@@ -53,31 +105,94 @@ func (a *App) MustRun() {
 	}
 }
 
+// Run serves until SIGINT or SIGTERM, or until the HTTP server fails, and
+// then shuts everything down.
 func (a *App) Run() error {
+	log := logger.GetLoggerFromCtx(a.ctx)
+
+	sigCtx, stop := signal.NotifyContext(a.ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	errCh := make(chan error, 1)
 	a.wg.Add(1)
 	go func() {
-		logger.GetLoggerFromCtx(a.ctx).Info("Server started on address", zap.Any("address", a.cfg.Host+":"+a.cfg.Port))
 		defer a.wg.Done()
+		log.Info("Server started on address", zap.String("address", a.cfg.Host+":"+a.cfg.Port))
 		if err := a.HiTalentServer.Run(); err != nil {
 			errCh <- err
-			a.cancel()
 		}
 	}()
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	if a.listener != nil {
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			log.Info("Event listener started", zap.String("channel", models.EventsChannel))
+			if err := a.listener.Run(a.ctx); err != nil {
+				log.Error("event listener stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	var runErr error
 	select {
-	case err := <-errCh:
-		logger.GetLoggerFromCtx(a.ctx).Error("error running app", zap.Error(err))
-		return err
-	case <-a.ctx.Done():
-		logger.GetLoggerFromCtx(a.ctx).Info("context done")
+	case runErr = <-errCh:
+		log.Error("error running app", zap.Error(runErr))
+	case <-sigCtx.Done():
+		log.Info("shutdown signal received, draining requests", zap.Duration("timeout", a.cfg.ShutdownTimeout))
 	}
 
-	return nil
+	return errors.Join(runErr, a.shutdown())
+}
+
+// shutdown drains in-flight requests within the configured timeout, then
+// stops background work and releases the database pools, if any.
+func (a *App) shutdown() error {
+	log := logger.GetLoggerFromCtx(a.ctx)
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	if err := a.HiTalentServer.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("http server shutdown: %w", err))
+	}
+
+	a.cancel()
+	a.wg.Wait()
+
+	// Flush spans still buffered for export.
+	if err := a.tracerProvider.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("trace flush: %w", err))
+	}
+	if a.pool != nil {
+		a.pool.Close()
+	}
+	if a.db != nil {
+		if err := a.db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("database close: %w", err))
+		}
+	}
+
+	if len(errs) == 0 {
+		log.Info("app stopped")
+	}
+	return errors.Join(errs...)
+}
+
+func newRateLimiter(cfg ratelimit.Config, db *gorm.DB) (ratelimit.Limiter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Backend == ratelimit.BackendPostgres {
+		if db == nil {
+			return nil, fmt.Errorf("rate limit backend %q requires %q storage", ratelimit.BackendPostgres, config.StoragePostgres)
+		}
+		return ratelimit.NewPostgres(db, cfg.Rate, cfg.Burst), nil
+	}
+	return ratelimit.NewMemory(cfg.Rate, cfg.Burst), nil
 }
 
-func runMigrations(db *gorm.DB, ctx context.Context) error {
+func runMigrations(db *gorm.DB, ctx context.Context, dialect string, dir string) error {
 	logger.GetLoggerFromCtx(ctx).Info("Running database migrations...")
 
 	sqlDB, err := db.DB()
@@ -86,12 +201,12 @@ func runMigrations(db *gorm.DB, ctx context.Context) error {
 		return err
 	}
 
-	if err := goose.SetDialect("postgres"); err != nil {
+	if err := goose.SetDialect(dialect); err != nil {
 		logger.GetLoggerFromCtx(ctx).Error("Failed to set goose dialect", zap.Error(err))
 		return err
 	}
 
-	if err := goose.Up(sqlDB, "migrations"); err != nil {
+	if err := goose.Up(sqlDB, dir); err != nil {
 		logger.GetLoggerFromCtx(ctx).Error("Migration failed", zap.Error(err))
 		return err
 	}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"TestHitalent/internal/transport"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+)
+
+// readinessChecks reports the instance ready only while the database answers
+// and its schema is at the newest migration in migrationsDir.
+func readinessChecks(db *sql.DB, migrationsDir string) ([]transport.HealthCheck, error) {
+	migrations, err := goose.CollectMigrations(migrationsDir, 0, goose.MaxVersion)
+	if err != nil {
+		return nil, fmt.Errorf("unable to collect migrations: %w", err)
+	}
+	last, err := migrations.Last()
+	if err != nil {
+		return nil, fmt.Errorf("unable to find latest migration: %w", err)
+	}
+
+	return []transport.HealthCheck{
+		{Name: "database", Check: db.PingContext},
+		{Name: "migrations", Check: func(ctx context.Context) error {
+			version, err := goose.GetDBVersionContext(ctx, db)
+			if err != nil {
+				return err
+			}
+			if version != last.Version {
+				return fmt.Errorf("schema at version %d, want %d", version, last.Version)
+			}
+			return nil
+		}},
+	}, nil
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"TestHitalent/internal/config"
+	"TestHitalent/internal/events"
+	"TestHitalent/internal/idempotency"
+	"TestHitalent/internal/metrics"
+	"TestHitalent/internal/repository"
+	"TestHitalent/internal/service"
+	"TestHitalent/internal/transport"
+	"TestHitalent/pkg/postgres"
+	"TestHitalent/pkg/sqlite"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"gorm.io/gorm"
+)
+
+// storage is the backend chats and idempotency keys are kept in.
+type storage struct {
+	repo        service.HiTalentRepositoryInterface
+	idempotency idempotency.Store
+	checks      []transport.HealthCheck
+	// listener relays events committed by any instance to the broker. It is
+	// nil when the repository publishes to the broker itself.
+	listener *events.Listener
+	// postgres is set only when chats are kept in Postgres, whose tables the
+	// rate limiter can share.
+	postgres *gorm.DB
+	// db is nil when chats are kept in memory.
+	db *sql.DB
+	// pool is set only when the pgx driver serves the hot paths.
+	pool *pgxpool.Pool
+}
+
+func newStorage(ctx context.Context, cfg *config.Config, tp *sdktrace.TracerProvider, m *metrics.Metrics, broker *events.Broker) (*storage, error) {
+	switch cfg.Storage {
+	case config.StoragePostgres:
+		return newPostgresStorage(ctx, cfg, tp, m, broker)
+	case config.StorageSQLite:
+		return newSQLiteStorage(ctx, cfg, m, broker)
+	case config.StorageMemory:
+		return &storage{
+			repo:        repository.NewMemoryRepository(broker),
+			idempotency: idempotency.NewMemory(cfg.Idempotency.TTL),
+		}, nil
+	default:
+		return nil, fmt.Errorf("storage must be %q, %q or %q, got %q", config.StoragePostgres, config.StorageSQLite, config.StorageMemory, cfg.Storage)
+	}
+}
+
+func newPostgresStorage(ctx context.Context, cfg *config.Config, tp *sdktrace.TracerProvider, m *metrics.Metrics, broker *events.Broker) (*storage, error) {
+	db, err := postgres.New(cfg.Postgres)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run migrations
+	if err := runMigrations(db, ctx, "postgres", migrationsDir); err != nil {
+		return nil, err
+	}
+
+	if err := db.Use(postgres.NewTracingPlugin(tp)); err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.RegisterDB(sqlDB, cfg.Postgres.Database); err != nil {
+		return nil, err
+	}
+
+	checks, err := readinessChecks(sqlDB, migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &storage{
+		idempotency: idempotency.NewPostgres(db, cfg.Idempotency.TTL),
+		checks:      checks,
+		postgres:    db,
+		db:          sqlDB,
+	}
+
+	switch cfg.Postgres.Driver {
+	case postgres.DriverPgx:
+		pool, err := postgres.NewPool(ctx, cfg.Postgres, postgres.NewQueryTracer(tp), repository.PreparePgxStatements)
+		if err != nil {
+			return nil, err
+		}
+		store.repo = repository.NewPgxRepository(db, pool)
+		store.pool = pool
+	case postgres.DriverGORM:
+		store.repo = repository.NewHiTalentRepository(db)
+	default:
+		return nil, fmt.Errorf("postgres driver must be %q or %q, got %q", postgres.DriverPgx, postgres.DriverGORM, cfg.Postgres.Driver)
+	}
+
+	store.listener = events.NewListener(cfg.Postgres.DSN(), broker, store.repo)
+	return store, nil
+}
+
+// newSQLiteStorage keeps idempotency keys in memory: a SQLite install runs a
+// single instance.
+func newSQLiteStorage(ctx context.Context, cfg *config.Config, m *metrics.Metrics, broker *events.Broker) (*storage, error) {
+	db, err := sqlite.New(cfg.SQLite)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run migrations
+	if err := runMigrations(db, ctx, "sqlite3", sqliteMigrationsDir); err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.RegisterDB(sqlDB, cfg.SQLite.Path); err != nil {
+		return nil, err
+	}
+
+	checks, err := readinessChecks(sqlDB, sqliteMigrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage{
+		repo:        repository.NewSQLiteRepository(db, broker),
+		idempotency: idempotency.NewMemory(cfg.Idempotency.TTL),
+		checks:      checks,
+		db:          sqlDB,
+	}, nil
+}
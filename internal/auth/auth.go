@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"TestHitalent/internal/models"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// APIKeyPrefix tells API keys apart from JWTs in the same bearer token slot.
+const APIKeyPrefix = "htk_"
+
+type principalKey struct{}
+
+// Config selects how JWTs are verified: HS256 with Secret, RS256 with the PEM
+// public key in PublicKeyFile, or both. Issuer and Audience are checked when set.
+type Config struct {
+	Secret        string `yaml:"jwt_secret" env:"JWT_SECRET"`
+	PublicKeyFile string `yaml:"jwt_public_key_file" env:"JWT_PUBLIC_KEY_FILE"`
+	Issuer        string `yaml:"jwt_issuer" env:"JWT_ISSUER"`
+	Audience      string `yaml:"jwt_audience" env:"JWT_AUDIENCE"`
+}
+
+func WithPrincipal(ctx context.Context, principal *models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller authenticated by the transport
+// middleware, or nil for unauthenticated contexts.
+func PrincipalFromContext(ctx context.Context) *models.Principal {
+	principal, _ := ctx.Value(principalKey{}).(*models.Principal)
+	return principal
+}
+
+func GenerateAPIKey() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// HashAPIKey returns the hex SHA-256 of a key. Keys are random 256-bit
+// values, so a fast hash is enough and lets keys be looked up by hash.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
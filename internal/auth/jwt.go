@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"TestHitalent/internal/models"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type claims struct {
+	jwt.RegisteredClaims
+	Name string `json:"name,omitempty"`
+}
+
+type JWTVerifier struct {
+	secret    []byte
+	publicKey *rsa.PublicKey
+	options   []jwt.ParserOption
+}
+
+func NewJWTVerifier(cfg Config) (*JWTVerifier, error) {
+	v := &JWTVerifier{}
+
+	var methods []string
+	if cfg.Secret != "" {
+		v.secret = []byte(cfg.Secret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.PublicKeyFile != "" {
+		pem, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read jwt public key: %w", err)
+		}
+		v.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse jwt public key: %w", err)
+		}
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	v.options = []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		v.options = append(v.options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		v.options = append(v.options, jwt.WithAudience(cfg.Audience))
+	}
+
+	return v, nil
+}
+
+func (v *JWTVerifier) Verify(token string) (*models.Principal, error) {
+	if v.secret == nil && v.publicKey == nil {
+		return nil, errors.New("jwt authentication is not configured")
+	}
+
+	var c claims
+	if _, err := jwt.ParseWithClaims(token, &c, v.key, v.options...); err != nil {
+		return nil, err
+	}
+	if c.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	return &models.Principal{
+		ID:     c.Subject,
+		Name:   c.Name,
+		Method: models.AuthMethodJWT,
+	}, nil
+}
+
+func (v *JWTVerifier) key(token *jwt.Token) (any, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return v.secret, nil
+	case *jwt.SigningMethodRSA:
+		return v.publicKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+}
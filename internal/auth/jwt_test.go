@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"TestHitalent/internal/models"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func signHS256(t *testing.T, secret string, c jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+func TestJWTVerifier_HS256(t *testing.T) {
+	v, err := NewJWTVerifier(Config{Secret: "secret", Issuer: "hitalent", Audience: "chat"})
+	require.NoError(t, err)
+
+	valid := jwt.MapClaims{
+		"sub":  "42",
+		"name": "Alice",
+		"iss":  "hitalent",
+		"aud":  "chat",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}
+
+	principal, err := v.Verify(signHS256(t, "secret", valid))
+	require.NoError(t, err)
+	require.Equal(t, &models.Principal{ID: "42", Name: "Alice", Method: models.AuthMethodJWT}, principal)
+
+	cases := []struct {
+		name   string
+		secret string
+		change func(c jwt.MapClaims)
+	}{
+		{name: "wrong secret", secret: "other"},
+		{name: "wrong issuer", secret: "secret", change: func(c jwt.MapClaims) { c["iss"] = "someone" }},
+		{name: "wrong audience", secret: "secret", change: func(c jwt.MapClaims) { c["aud"] = "billing" }},
+		{name: "expired", secret: "secret", change: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{name: "no expiry", secret: "secret", change: func(c jwt.MapClaims) { delete(c, "exp") }},
+		{name: "no subject", secret: "secret", change: func(c jwt.MapClaims) { delete(c, "sub") }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := jwt.MapClaims{}
+			for k, v := range valid {
+				c[k] = v
+			}
+			if tc.change != nil {
+				tc.change(c)
+			}
+			_, err := v.Verify(signHS256(t, tc.secret, c))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestJWTVerifier_RS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "jwt.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	v, err := NewJWTVerifier(Config{PublicKeyFile: keyFile})
+	require.NoError(t, err)
+
+	c := jwt.MapClaims{"sub": "7", "exp": time.Now().Add(time.Hour).Unix()}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, c).SignedString(key)
+	require.NoError(t, err)
+
+	principal, err := v.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, "7", principal.ID)
+
+	// HS256 is not enabled, so an HMAC token must not be accepted.
+	_, err = v.Verify(signHS256(t, "secret", c))
+	require.Error(t, err)
+}
+
+func TestJWTVerifier_NotConfigured(t *testing.T) {
+	v, err := NewJWTVerifier(Config{})
+	require.NoError(t, err)
+
+	_, err = v.Verify(signHS256(t, "secret", jwt.MapClaims{"sub": "1"}))
+	require.Error(t, err)
+}
+
+func TestAPIKey(t *testing.T) {
+	key, err := GenerateAPIKey()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(key, APIKeyPrefix))
+
+	other, err := GenerateAPIKey()
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+
+	require.Len(t, HashAPIKey(key), 64)
+	require.Equal(t, HashAPIKey(key), HashAPIKey(key))
+	require.NotEqual(t, HashAPIKey(key), HashAPIKey(other))
+}
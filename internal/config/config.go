@@ -1,16 +1,48 @@
 package config
 
 import (
+	"TestHitalent/internal/auth"
+	"TestHitalent/internal/idempotency"
+	"TestHitalent/internal/ratelimit"
 	"TestHitalent/pkg/postgres"
+	"TestHitalent/pkg/sqlite"
+	"TestHitalent/pkg/tracing"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
 )
 
+const (
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+	StorageMemory   = "memory"
+)
+
 type Config struct {
-	Host     string `yaml:"host" env:"HOST" env-default:"0.0.0.0"`
-	Port     string `yaml:"port" env:"PORT" env-default:"4047"`
-	Postgres postgres.Config
+	Host string `yaml:"host" env:"HOST" env-default:"0.0.0.0"`
+	Port string `yaml:"port" env:"PORT" env-default:"4047"`
+	// ReadTimeout and WriteTimeout bound ordinary requests; SSE and WebSocket
+	// streams lift them once established.
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" env-default:"15s"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" env-default:"30s"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" env-default:"60s"`
+	// ShutdownTimeout is how long in-flight requests may take to finish
+	// after SIGINT or SIGTERM.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" env-default:"20s"`
+	// ShutdownDelay is how long /readyz reports failure before the listener
+	// closes, giving load balancers time to stop routing to the instance.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env:"SHUTDOWN_DELAY" env-default:"0s"`
+	// Storage selects where chats are kept: in Postgres, in a SQLite file
+	// for single-instance installs, or in process memory for running locally
+	// and in tests without a database.
+	Storage     string `yaml:"storage" env:"STORAGE" env-default:"postgres"`
+	Postgres    postgres.Config
+	SQLite      sqlite.Config
+	Auth        auth.Config
+	Tracing     tracing.Config
+	RateLimit   ratelimit.Config
+	Idempotency idempotency.Config
 }
 
 func NewConfig() (*Config, error) {
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"TestHitalent/internal/models"
+	"sync"
+)
+
+// Broker fans chat events out to in-process subscribers. Publishing never
+// blocks: a subscriber whose buffer is full is dropped and its channel is
+// closed, so it can reconnect and catch up from storage.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[int]map[chan *models.Event]struct{}
+	bufferSize  int
+}
+
+func NewBroker(bufferSize int) *Broker {
+	return &Broker{
+		subscribers: make(map[int]map[chan *models.Event]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+func (b *Broker) Subscribe(chatId int) (<-chan *models.Event, func()) {
+	ch := make(chan *models.Event, b.bufferSize)
+
+	b.mu.Lock()
+	if b.subscribers[chatId] == nil {
+		b.subscribers[chatId] = make(map[chan *models.Event]struct{})
+	}
+	b.subscribers[chatId][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(chatId, ch)
+	}
+}
+
+func (b *Broker) Publish(event *models.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[event.ChatID] {
+		select {
+		case ch <- event:
+		default:
+			b.remove(event.ChatID, ch)
+		}
+	}
+}
+
+// DisconnectAll drops every subscriber, e.g. after events may have been missed.
+func (b *Broker) DisconnectAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for chatId, subs := range b.subscribers {
+		for ch := range subs {
+			b.remove(chatId, ch)
+		}
+	}
+}
+
+// remove must be called with b.mu held.
+func (b *Broker) remove(chatId int, ch chan *models.Event) {
+	subs, ok := b.subscribers[chatId]
+	if !ok {
+		return
+	}
+	if _, ok = subs[ch]; !ok {
+		return
+	}
+	delete(subs, ch)
+	close(ch)
+	if len(subs) == 0 {
+		delete(b.subscribers, chatId)
+	}
+}
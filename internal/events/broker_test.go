@@ -0,0 +1,72 @@
+package events
+
+import (
+	"TestHitalent/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_PublishToChatSubscribers(t *testing.T) {
+	b := NewBroker(4)
+
+	first, cancelFirst := b.Subscribe(1)
+	defer cancelFirst()
+	second, cancelSecond := b.Subscribe(1)
+	defer cancelSecond()
+	other, cancelOther := b.Subscribe(2)
+	defer cancelOther()
+
+	event := &models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: &models.Message{ID: 1, ChatID: 1}}
+	b.Publish(event)
+
+	require.Equal(t, event, <-first)
+	require.Equal(t, event, <-second)
+	require.Empty(t, other)
+}
+
+func TestBroker_CancelClosesChannel(t *testing.T) {
+	b := NewBroker(1)
+
+	ch, cancel := b.Subscribe(1)
+	cancel()
+	cancel()
+
+	_, ok := <-ch
+	require.False(t, ok)
+
+	b.Publish(&models.Event{Type: models.EventChatDeleted, ChatID: 1})
+}
+
+func TestBroker_DropsSlowSubscriber(t *testing.T) {
+	b := NewBroker(1)
+
+	slow, cancel := b.Subscribe(1)
+	defer cancel()
+
+	b.Publish(&models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: &models.Message{ID: 1}})
+	b.Publish(&models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: &models.Message{ID: 2}})
+
+	event, ok := <-slow
+	require.True(t, ok)
+	require.Equal(t, 1, event.Message.ID)
+
+	_, ok = <-slow
+	require.False(t, ok)
+}
+
+func TestBroker_DisconnectAll(t *testing.T) {
+	b := NewBroker(1)
+
+	first, cancelFirst := b.Subscribe(1)
+	defer cancelFirst()
+	second, cancelSecond := b.Subscribe(2)
+	defer cancelSecond()
+
+	b.DisconnectAll()
+
+	_, ok := <-first
+	require.False(t, ok)
+	_, ok = <-second
+	require.False(t, ok)
+}
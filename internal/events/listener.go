@@ -0,0 +1,105 @@
+package events
+
+import (
+	"TestHitalent/internal/models"
+	"TestHitalent/pkg/logger"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// listenerRetryInterval is how long the listener waits before reconnecting.
+const listenerRetryInterval = 2 * time.Second
+
+type MessageLoader interface {
+	GetMessage(ctx context.Context, chatId int, messageId int) (*models.Message, error)
+}
+
+// Listener relays chat events announced through Postgres NOTIFY, by this or
+// any other instance, to the local Broker.
+type Listener struct {
+	dsn    string
+	broker *Broker
+	loader MessageLoader
+}
+
+func NewListener(dsn string, broker *Broker, loader MessageLoader) *Listener {
+	return &Listener{
+		dsn:    dsn,
+		broker: broker,
+		loader: loader,
+	}
+}
+
+// Run listens for notifications until ctx is done, reconnecting whenever the
+// connection is lost.
+func (l *Listener) Run(ctx context.Context) error {
+	reconnect := false
+	for {
+		err := l.listen(ctx, reconnect)
+		if ctx.Err() != nil {
+			return nil
+		}
+		logger.GetLoggerFromCtx(ctx).Warn("event listener disconnected", zap.Error(err))
+		reconnect = true
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(listenerRetryInterval):
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, reconnect bool) error {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{models.EventsChannel}.Sanitize()); err != nil {
+		return err
+	}
+
+	if reconnect {
+		// Notifications sent while we were away are lost; make subscribers
+		// reconnect and catch up from storage.
+		l.broker.DisconnectAll()
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		l.handle(ctx, notification.Payload)
+	}
+}
+
+func (l *Listener) handle(ctx context.Context, payload string) {
+	var notification models.EventNotification
+	if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+		logger.GetLoggerFromCtx(ctx).Warn("invalid event notification", zap.String("payload", payload), zap.Error(err))
+		return
+	}
+
+	event := &models.Event{
+		Type:   notification.Type,
+		ChatID: notification.ChatID,
+	}
+
+	if notification.MessageID > 0 {
+		message, err := l.loader.GetMessage(ctx, notification.ChatID, notification.MessageID)
+		if err != nil {
+			logger.GetLoggerFromCtx(ctx).Warn("failed to load notified message", zap.Int("message_id", notification.MessageID), zap.Error(err))
+			return
+		}
+		event.Message = message
+	}
+
+	l.broker.Publish(event)
+}
@@ -0,0 +1,43 @@
+package events
+
+import (
+	"TestHitalent/internal/models"
+	"TestHitalent/pkg/logger"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubLoader map[int]*models.Message
+
+func (l stubLoader) GetMessage(_ context.Context, chatId int, messageId int) (*models.Message, error) {
+	message, ok := l[messageId]
+	if !ok || message.ChatID != chatId {
+		return nil, suberrors.ErrMessageNotFound
+	}
+	return message, nil
+}
+
+func TestListener_Handle(t *testing.T) {
+	ctx, err := logger.New(context.Background())
+	require.NoError(t, err)
+
+	message := &models.Message{ID: 7, ChatID: 1, Text: "Hello"}
+	b := NewBroker(4)
+	l := NewListener("", b, stubLoader{7: message})
+
+	live, cancel := b.Subscribe(1)
+	defer cancel()
+
+	l.handle(ctx, `{"type":"message.created","chat_id":1,"message_id":7}`)
+	require.Equal(t, &models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: message}, <-live)
+
+	// Malformed payloads and vanished messages are skipped.
+	l.handle(ctx, `not json`)
+	l.handle(ctx, `{"type":"message.created","chat_id":1,"message_id":8}`)
+
+	l.handle(ctx, `{"type":"chat.deleted","chat_id":1}`)
+	require.Equal(t, &models.Event{Type: models.EventChatDeleted, ChatID: 1}, <-live)
+}
@@ -0,0 +1,61 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// Config sets how long a completed request can be replayed by its key.
+type Config struct {
+	TTL time.Duration `yaml:"idempotency_ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+}
+
+// lockTimeout is how long a claimed key stays locked without a response. A
+// request that crashed before completing frees its key after it.
+const lockTimeout = time.Minute
+
+// sweepInterval is how often a store deletes expired keys.
+const sweepInterval = time.Minute
+
+// Response is what the first request with a key answered.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record is the stored state of a key someone else claimed.
+type Record struct {
+	RequestHash string
+	// Response is nil while the request that claimed the key is running.
+	Response *Response
+}
+
+// Store remembers responses by idempotency key.
+//
+// A claim that outlives lockTimeout can be taken over by a retry, so the
+// request that lost it may still finish. Claims therefore carry a token, and
+// Complete and Release do nothing unless it is the token of the current one.
+type Store interface {
+	// Begin claims key for a request with hash and returns the claim token.
+	// If the key is already claimed and has not expired it returns the
+	// existing record and an empty token instead.
+	Begin(ctx context.Context, key, hash string) (*Record, string, error)
+	// Complete stores the response of the request that claimed key with token.
+	Complete(ctx context.Context, key, token string, resp *Response) error
+	// Release frees key without a response so the request can be retried.
+	Release(ctx context.Context, key, token string) error
+}
+
+// newClaimToken returns a random token telling one claim of a key from the
+// next.
+func newClaimToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,88 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	now := time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC)
+	m := NewMemory(time.Hour)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	rec, first, err := m.Begin(ctx, "k", "hash")
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+	require.Nil(t, rec)
+
+	// Claimed but not completed yet.
+	rec, token, err := m.Begin(ctx, "k", "hash")
+	require.NoError(t, err)
+	require.Empty(t, token)
+	require.Equal(t, &Record{RequestHash: "hash"}, rec)
+
+	resp := &Response{Status: http.StatusCreated, Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"id":1}`)}
+	require.NoError(t, m.Complete(ctx, "k", first, resp))
+
+	rec, token, err = m.Begin(ctx, "k", "other")
+	require.NoError(t, err)
+	require.Empty(t, token)
+	require.Equal(t, &Record{RequestHash: "hash", Response: resp}, rec)
+
+	// Expired keys are free again.
+	now = now.Add(time.Hour)
+	_, token, err = m.Begin(ctx, "k", "other")
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+}
+
+func TestMemory_Release(t *testing.T) {
+	m := NewMemory(time.Hour)
+	ctx := context.Background()
+
+	_, token, err := m.Begin(ctx, "k", "hash")
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NoError(t, m.Release(ctx, "k", token))
+
+	_, token, err = m.Begin(ctx, "k", "hash")
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+}
+
+func TestMemory_StaleLockIsRetoken(t *testing.T) {
+	now := time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC)
+	m := NewMemory(time.Hour)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, token, err := m.Begin(ctx, "k", "hash")
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	now = now.Add(lockTimeout)
+	_, retry, err := m.Begin(ctx, "k", "hash")
+	require.NoError(t, err)
+	require.NotEmpty(t, retry)
+	require.NotEqual(t, token, retry)
+
+	// The request that lost the claim finishing late leaves the retry's alone.
+	require.NoError(t, m.Release(ctx, "k", token))
+	require.NoError(t, m.Complete(ctx, "k", token, &Response{Status: http.StatusInternalServerError}))
+	rec, claimed, err := m.Begin(ctx, "k", "hash")
+	require.NoError(t, err)
+	require.Empty(t, claimed)
+	require.Equal(t, &Record{RequestHash: "hash"}, rec)
+
+	resp := &Response{Status: http.StatusCreated}
+	require.NoError(t, m.Complete(ctx, "k", retry, resp))
+	require.NoError(t, m.Release(ctx, "k", token))
+	rec, _, err = m.Begin(ctx, "k", "hash")
+	require.NoError(t, err)
+	require.Equal(t, resp, rec.Response)
+}
@@ -0,0 +1,94 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory keeps keys in process memory. Keys are not shared between instances
+// and do not survive a restart.
+type Memory struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	token     string
+	hash      string
+	response  *Response
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+func NewMemory(ttl time.Duration) *Memory {
+	return &Memory{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+func (m *Memory) Begin(ctx context.Context, key, hash string) (*Record, string, error) {
+	token, err := newClaimToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	if e, ok := m.entries[key]; ok && !e.reclaimable(now) {
+		return &Record{RequestHash: e.hash, Response: e.response}, "", nil
+	}
+	m.entries[key] = &memoryEntry{token: token, hash: hash, createdAt: now, expiresAt: now.Add(m.ttl)}
+	return nil, token, nil
+}
+
+func (m *Memory) Complete(ctx context.Context, key, token string, resp *Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[key]; ok && e.token == token {
+		e.response = resp
+	}
+	return nil
+}
+
+func (m *Memory) Release(ctx context.Context, key, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[key]; ok && e.token == token && e.response == nil {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// reclaimable reports whether the key may be claimed again: it expired, or
+// the request holding it never completed.
+func (e *memoryEntry) reclaimable(now time.Time) bool {
+	if !now.Before(e.expiresAt) {
+		return true
+	}
+	return e.response == nil && now.Sub(e.createdAt) >= lockTimeout
+}
+
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, e := range m.entries {
+		if !now.Before(e.expiresAt) {
+			delete(m.entries, key)
+		}
+	}
+}
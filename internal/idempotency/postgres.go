@@ -0,0 +1,119 @@
+package idempotency
+
+import (
+	"TestHitalent/pkg/logger"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Postgres keeps keys in the idempotency_keys table, shared by all instances.
+// Expiry is decided by the database clock.
+type Postgres struct {
+	db  *gorm.DB
+	ttl time.Duration
+
+	// lastSweep is the unix nano time this instance last deleted expired keys.
+	lastSweep atomic.Int64
+}
+
+type postgresRecord struct {
+	RequestHash    string
+	StatusCode     *int
+	ResponseHeader []byte
+	ResponseBody   []byte
+}
+
+func NewPostgres(db *gorm.DB, ttl time.Duration) *Postgres {
+	return &Postgres{db: db, ttl: ttl}
+}
+
+func (p *Postgres) Begin(ctx context.Context, key, hash string) (*Record, string, error) {
+	token, err := newClaimToken()
+	if err != nil {
+		return nil, "", err
+	}
+	p.sweep(ctx)
+
+	// The key can expire between the claim and the read; claiming again
+	// then succeeds.
+	for range 2 {
+		res := p.db.WithContext(ctx).Exec(`INSERT INTO idempotency_keys (key, claim_token, request_hash, created_at, expires_at)
+			VALUES (?, ?, ?, now(), now() + make_interval(secs => ?))
+			ON CONFLICT (key) DO UPDATE SET
+				claim_token = EXCLUDED.claim_token,
+				request_hash = EXCLUDED.request_hash,
+				status_code = NULL,
+				response_header = NULL,
+				response_body = NULL,
+				created_at = EXCLUDED.created_at,
+				expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= now()
+				OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at <= now() - make_interval(secs => ?))`,
+			key, token, hash, p.ttl.Seconds(), lockTimeout.Seconds())
+		if res.Error != nil {
+			return nil, "", res.Error
+		}
+		if res.RowsAffected > 0 {
+			return nil, token, nil
+		}
+
+		var rows []postgresRecord
+		err := p.db.WithContext(ctx).Raw(`SELECT request_hash, status_code, response_header, response_body
+			FROM idempotency_keys WHERE key = ? AND expires_at > now()`, key).Scan(&rows).Error
+		if err != nil {
+			return nil, "", err
+		}
+		if len(rows) > 0 {
+			rec, err := rows[0].record()
+			return rec, "", err
+		}
+	}
+	return nil, "", fmt.Errorf("idempotency key %q could not be claimed", key)
+}
+
+func (p *Postgres) Complete(ctx context.Context, key, token string, resp *Response) error {
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+	return p.db.WithContext(ctx).Exec(`UPDATE idempotency_keys
+		SET status_code = ?, response_header = ?, response_body = ? WHERE key = ? AND claim_token = ?`,
+		resp.Status, string(header), resp.Body, key, token).Error
+}
+
+func (p *Postgres) Release(ctx context.Context, key, token string) error {
+	return p.db.WithContext(ctx).
+		Exec(`DELETE FROM idempotency_keys WHERE key = ? AND claim_token = ? AND status_code IS NULL`, key, token).Error
+}
+
+func (r postgresRecord) record() (*Record, error) {
+	rec := &Record{RequestHash: r.RequestHash}
+	if r.StatusCode == nil {
+		return rec, nil
+	}
+
+	rec.Response = &Response{Status: *r.StatusCode, Body: r.ResponseBody}
+	if err := json.Unmarshal(r.ResponseHeader, &rec.Response.Header); err != nil {
+		return nil, fmt.Errorf("unable to decode stored response header: %w", err)
+	}
+	return rec, nil
+}
+
+// sweep deletes expired keys at most once per sweep interval per instance.
+func (p *Postgres) sweep(ctx context.Context) {
+	now := time.Now().UnixNano()
+	last := p.lastSweep.Load()
+	if now-last < int64(sweepInterval) || !p.lastSweep.CompareAndSwap(last, now) {
+		return
+	}
+
+	if err := p.db.WithContext(ctx).Exec(`DELETE FROM idempotency_keys WHERE expires_at <= now()`).Error; err != nil {
+		logger.GetLoggerFromCtx(ctx).Error("idempotency key sweep failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/repository/mocks"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRepository(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	next := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	next.EXPECT().CreateChat(gomock.Any(), gomock.Any(), "42").Return(&models.Chat{ID: 1, Messages: []*models.Message{{ID: 1}, {ID: 2}}}, nil).Times(1)
+	next.EXPECT().CreateMessage(gomock.Any(), 1, gomock.Any()).Return(&models.Message{ID: 3}, nil).Times(1)
+	next.EXPECT().CreateMessage(gomock.Any(), 2, gomock.Any()).Return(nil, suberrors.ErrChatNotFound).Times(1)
+	next.EXPECT().DeleteChat(gomock.Any(), 1).Return(errors.New("connection reset")).Times(1)
+
+	m := New()
+	repo := NewRepository(next, m)
+
+	_, err := repo.CreateChat(context.Background(), &models.Chat{Title: "Chat"}, "42")
+	require.NoError(t, err)
+	_, err = repo.CreateMessage(context.Background(), 1, &models.Message{Text: "Hi"})
+	require.NoError(t, err)
+	_, err = repo.CreateMessage(context.Background(), 2, &models.Message{Text: "Hi"})
+	require.ErrorIs(t, err, suberrors.ErrChatNotFound)
+	require.Error(t, repo.DeleteChat(context.Background(), 1))
+
+	body := scrape(t, m)
+	require.Contains(t, body, "hitalent_chats_created_total 1\n")
+	// Initial messages of the chat count as posted.
+	require.Contains(t, body, "hitalent_messages_posted_total 3\n")
+	require.Contains(t, body, "hitalent_chats_deleted_total 0\n")
+	require.Contains(t, body, `hitalent_repository_operation_duration_seconds_count{operation="create_message",result="ok"} 1`)
+	require.Contains(t, body, `hitalent_repository_operation_duration_seconds_count{operation="create_message",result="not_found"} 1`)
+	require.Contains(t, body, `hitalent_repository_operation_duration_seconds_count{operation="delete_chat",result="error"} 1`)
+}
+
+func TestObserveRequest(t *testing.T) {
+	m := New()
+	m.ObserveRequest("GET", "GET /api/v1/chats/{id}", 200, 15*time.Millisecond)
+	m.ObserveRequest("GET", "GET /api/v1/chats/{id}", 200, 5*time.Millisecond)
+
+	body := scrape(t, m)
+	require.Contains(t, body, `hitalent_http_requests_total{method="GET",route="GET /api/v1/chats/{id}",status="200"} 2`)
+	require.Contains(t, body, `hitalent_http_request_duration_seconds_count{method="GET",route="GET /api/v1/chats/{id}",status="200"} 2`)
+	require.Contains(t, body, "go_goroutines")
+}
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, 200, w.Code)
+
+	body := w.Body.String()
+	require.True(t, strings.HasPrefix(body, "# HELP"))
+	return body
+}
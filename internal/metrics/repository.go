@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/service"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"errors"
+	"time"
+)
+
+// Repository times every call to the wrapped repository and counts the
+// business events behind successful writes.
+type Repository struct {
+	next    service.HiTalentRepositoryInterface
+	metrics *Metrics
+}
+
+func NewRepository(next service.HiTalentRepositoryInterface, m *Metrics) *Repository {
+	return &Repository{
+		next:    next,
+		metrics: m,
+	}
+}
+
+var notFoundErrors = []error{
+	suberrors.ErrChatNotFound,
+	suberrors.ErrMessageNotFound,
+	suberrors.ErrMemberNotFound,
+	suberrors.ErrAPIKeyNotFound,
+}
+
+// operationResult separates lookups of missing rows, which are normal, from
+// failures worth alerting on.
+func operationResult(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	for _, e := range notFoundErrors {
+		if errors.Is(err, e) {
+			return "not_found"
+		}
+	}
+	return "error"
+}
+
+func observe[T any](r *Repository, operation string, call func() (T, error)) (T, error) {
+	start := time.Now()
+	v, err := call()
+	r.metrics.observeOperation(operation, operationResult(err), time.Since(start))
+	return v, err
+}
+
+func observeErr(r *Repository, operation string, call func() error) error {
+	_, err := observe(r, operation, func() (struct{}, error) {
+		return struct{}{}, call()
+	})
+	return err
+}
+
+func (r *Repository) CreateChat(ctx context.Context, chat *models.Chat, ownerId string) (*models.Chat, error) {
+	created, err := observe(r, "create_chat", func() (*models.Chat, error) {
+		return r.next.CreateChat(ctx, chat, ownerId)
+	})
+	if err == nil {
+		r.metrics.chatsCreated.Inc()
+		r.metrics.messagesPosted.Add(float64(len(created.Messages)))
+	}
+	return created, err
+}
+
+func (r *Repository) GetChat(ctx context.Context, chatId int, query *models.MessagesQuery) (*models.ChatAndMessagesResponse, error) {
+	return observe(r, "get_chat", func() (*models.ChatAndMessagesResponse, error) {
+		return r.next.GetChat(ctx, chatId, query)
+	})
+}
+
+func (r *Repository) ChatExists(ctx context.Context, chatId int) error {
+	return observeErr(r, "chat_exists", func() error {
+		return r.next.ChatExists(ctx, chatId)
+	})
+}
+
+func (r *Repository) ListChats(ctx context.Context, query *models.ChatsQuery) (*models.ChatListResponse, error) {
+	return observe(r, "list_chats", func() (*models.ChatListResponse, error) {
+		return r.next.ListChats(ctx, query)
+	})
+}
+
+func (r *Repository) UpdateChat(ctx context.Context, chatId int, title string, version int) (*models.Chat, error) {
+	return observe(r, "update_chat", func() (*models.Chat, error) {
+		return r.next.UpdateChat(ctx, chatId, title, version)
+	})
+}
+
+func (r *Repository) CreateMessage(ctx context.Context, chatId int, message *models.Message) (*models.Message, error) {
+	created, err := observe(r, "create_message", func() (*models.Message, error) {
+		return r.next.CreateMessage(ctx, chatId, message)
+	})
+	if err == nil {
+		r.metrics.messagesPosted.Inc()
+	}
+	return created, err
+}
+
+func (r *Repository) CreateMessages(ctx context.Context, chatId int, messages []*models.Message) ([]*models.Message, error) {
+	created, err := observe(r, "create_messages", func() ([]*models.Message, error) {
+		return r.next.CreateMessages(ctx, chatId, messages)
+	})
+	if err == nil {
+		r.metrics.messagesPosted.Add(float64(len(created)))
+	}
+	return created, err
+}
+
+func (r *Repository) DeleteChat(ctx context.Context, chatId int) error {
+	err := observeErr(r, "delete_chat", func() error {
+		return r.next.DeleteChat(ctx, chatId)
+	})
+	if err == nil {
+		r.metrics.chatsDeleted.Inc()
+	}
+	return err
+}
+
+func (r *Repository) UpdateMessage(ctx context.Context, chatId int, messageId int, text string) (*models.Message, error) {
+	return observe(r, "update_message", func() (*models.Message, error) {
+		return r.next.UpdateMessage(ctx, chatId, messageId, text)
+	})
+}
+
+func (r *Repository) DeleteMessage(ctx context.Context, chatId int, messageId int) error {
+	return observeErr(r, "delete_message", func() error {
+		return r.next.DeleteMessage(ctx, chatId, messageId)
+	})
+}
+
+func (r *Repository) ListMessagesAfter(ctx context.Context, chatId int, afterId int, limit int) ([]*models.Message, error) {
+	return observe(r, "list_messages_after", func() ([]*models.Message, error) {
+		return r.next.ListMessagesAfter(ctx, chatId, afterId, limit)
+	})
+}
+
+func (r *Repository) Search(ctx context.Context, query *models.SearchQuery) (*models.SearchResponse, error) {
+	return observe(r, "search", func() (*models.SearchResponse, error) {
+		return r.next.Search(ctx, query)
+	})
+}
+
+func (r *Repository) GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	return observe(r, "get_api_key", func() (*models.APIKey, error) {
+		return r.next.GetAPIKey(ctx, keyHash)
+	})
+}
+
+func (r *Repository) GetChatRole(ctx context.Context, chatId int, userId string) (string, error) {
+	return observe(r, "get_chat_role", func() (string, error) {
+		return r.next.GetChatRole(ctx, chatId, userId)
+	})
+}
+
+func (r *Repository) ListChatMembers(ctx context.Context, chatId int) ([]*models.ChatMember, error) {
+	return observe(r, "list_chat_members", func() ([]*models.ChatMember, error) {
+		return r.next.ListChatMembers(ctx, chatId)
+	})
+}
+
+func (r *Repository) AddChatMember(ctx context.Context, member *models.ChatMember) (*models.ChatMember, error) {
+	return observe(r, "add_chat_member", func() (*models.ChatMember, error) {
+		return r.next.AddChatMember(ctx, member)
+	})
+}
+
+func (r *Repository) RemoveChatMember(ctx context.Context, chatId int, userId string) error {
+	return observeErr(r, "remove_chat_member", func() error {
+		return r.next.RemoveChatMember(ctx, chatId, userId)
+	})
+}
+
+func (r *Repository) CountChatOwners(ctx context.Context, chatId int) (int, error) {
+	return observe(r, "count_chat_owners", func() (int, error) {
+		return r.next.CountChatOwners(ctx, chatId)
+	})
+}
+
+func (r *Repository) GetMessage(ctx context.Context, chatId int, messageId int) (*models.Message, error) {
+	return observe(r, "get_message", func() (*models.Message, error) {
+		return r.next.GetMessage(ctx, chatId, messageId)
+	})
+}
@@ -3,7 +3,12 @@ package models
 import "time"
 
 type Chat struct {
-	ID        int       `json:"id" gorm:"primaryKey"`
-	Title     string    `json:"title" gorm:"type:varchar(255);not null" validate:"required,min=1,max=200"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID             int        `json:"id" gorm:"primaryKey"`
+	Title          string     `json:"title" gorm:"type:varchar(255);not null" validate:"required,min=1,max=200"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	Version        int        `json:"version" gorm:"not null;default:1"`
+	LastActivityAt *time.Time `json:"last_activity_at,omitempty" gorm:"->"`
+	// Messages seed a new chat; they are stored with it or not at all.
+	Messages []*Message `json:"messages,omitempty" gorm:"-" validate:"max=100,dive,required"`
 }
@@ -2,5 +2,7 @@ package models
 
 type ChatAndMessagesResponse struct {
 	*Chat
-	Messages []*Message `json:"messages"`
+	Messages   []*Message `json:"messages"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	HasMore    bool       `json:"has_more"`
 }
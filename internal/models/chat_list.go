@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	ChatSortCreatedAt    = "created_at"
+	ChatSortLastActivity = "last_activity"
+
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+type ChatsQuery struct {
+	Limit       int
+	Title       string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Sort        string
+	Order       string
+	Cursor      *ChatCursor
+	MemberID    string // when set, only chats the user is a member of
+}
+
+type ChatListResponse struct {
+	Chats      []*Chat `json:"chats"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	HasMore    bool    `json:"has_more"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+const (
+	ChatRoleOwner    = "owner"
+	ChatRoleMember   = "member"
+	ChatRoleReadOnly = "read_only"
+)
+
+// ChatMember grants a user access to a chat. Owners manage the chat and its
+// members, members read and write messages, read-only members only read.
+type ChatMember struct {
+	ChatID    int       `json:"chat_id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"primaryKey;type:varchar(255)" validate:"required,max=255"`
+	Role      string    `json:"role" gorm:"type:varchar(16);not null" validate:"required,oneof=owner member read_only"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
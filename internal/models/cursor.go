@@ -0,0 +1,137 @@
+package models
+
+import (
+	"TestHitalent/pkg/suberrors"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type MessageCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+type MessagesQuery struct {
+	Limit    int
+	Before   *MessageCursor
+	After    *MessageCursor
+	SenderID string // when set, only messages written by this user
+}
+
+func NewMessageCursor(message *Message) *MessageCursor {
+	return &MessageCursor{
+		CreatedAt: message.CreatedAt,
+		ID:        message.ID,
+	}
+}
+
+func (c *MessageCursor) Encode() string {
+	return encodeCursor(c.CreatedAt, c.ID)
+}
+
+func DecodeMessageCursor(cursor string) (*MessageCursor, error) {
+	createdAt, id, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	return &MessageCursor{
+		CreatedAt: createdAt,
+		ID:        id,
+	}, nil
+}
+
+// ChatCursor points at a chat in a sorted listing. SortValue holds either
+// created_at or last activity, depending on the sort the cursor came from;
+// Sort and Order record that listing so the cursor cannot be replayed
+// against a different one.
+type ChatCursor struct {
+	Sort      string
+	Order     string
+	SortValue time.Time
+	ID        int
+}
+
+func (c *ChatCursor) Encode() string {
+	raw := fmt.Sprintf("%s:%s:", c.Sort, c.Order)
+	return base64.RawURLEncoding.EncodeToString(append([]byte(raw), encodeCursorRaw(c.SortValue, c.ID)...))
+}
+
+func DecodeChatCursor(cursor string) (*ChatCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, suberrors.ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return nil, suberrors.ErrInvalidCursor
+	}
+	sortValue, id, err := decodeCursorRaw(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	return &ChatCursor{
+		Sort:      parts[0],
+		Order:     parts[1],
+		SortValue: sortValue,
+		ID:        id,
+	}, nil
+}
+
+// SearchCursor points at a position in ranked search results. Ranks are not
+// unique and shift as messages are edited, so results are paged by offset.
+type SearchCursor struct {
+	Offset int
+}
+
+func (c *SearchCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(c.Offset)))
+}
+
+func DecodeSearchCursor(cursor string) (*SearchCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, suberrors.ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset <= 0 {
+		return nil, suberrors.ErrInvalidCursor
+	}
+	return &SearchCursor{Offset: offset}, nil
+}
+
+func encodeCursor(t time.Time, id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(encodeCursorRaw(t, id)))
+}
+
+func encodeCursorRaw(t time.Time, id int) string {
+	return fmt.Sprintf("%d:%d", t.UnixNano(), id)
+}
+
+func decodeCursor(cursor string) (time.Time, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, suberrors.ErrInvalidCursor
+	}
+	return decodeCursorRaw(string(raw))
+}
+
+func decodeCursorRaw(raw string) (time.Time, int, error) {
+	nanosStr, idStr, ok := strings.Cut(raw, ":")
+	if !ok {
+		return time.Time{}, 0, suberrors.ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(nanosStr, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, suberrors.ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		return time.Time{}, 0, suberrors.ErrInvalidCursor
+	}
+
+	return time.Unix(0, nanos).UTC(), id, nil
+}
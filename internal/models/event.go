@@ -0,0 +1,24 @@
+package models
+
+const (
+	EventMessageCreated = "message.created"
+	EventChatDeleted    = "chat.deleted"
+)
+
+// EventsChannel is the Postgres NOTIFY channel chat events are sent on.
+const EventsChannel = "chat_events"
+
+type Event struct {
+	Type    string   `json:"type"`
+	ChatID  int      `json:"chat_id"`
+	Message *Message `json:"message,omitempty"`
+}
+
+// EventNotification is the NOTIFY payload of an Event. It carries ids only,
+// since payloads are limited to 8000 bytes, and listeners load the message
+// from storage.
+type EventNotification struct {
+	Type      string `json:"type"`
+	ChatID    int    `json:"chat_id"`
+	MessageID int    `json:"message_id,omitempty"`
+}
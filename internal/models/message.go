@@ -2,9 +2,23 @@ package models
 
 import "time"
 
+const DeletedMessagePlaceholder = "This message was deleted"
+
 type Message struct {
+	ID         int        `json:"id" gorm:"primaryKey"`
+	ChatID     int        `json:"chat_id" gorm:"not null;constraint:OnDelete:CASCADE"`
+	SenderID   string     `json:"sender_id,omitempty" gorm:"type:varchar(255)" validate:"max=255"`
+	SenderName string     `json:"sender_name,omitempty" gorm:"type:varchar(255)" validate:"max=255"`
+	Text       string     `json:"text" gorm:"type:text;not null" validate:"required,min=1,max=5000"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	EditedAt   *time.Time `json:"edited_at,omitempty"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+// MessageRevision keeps the text a message had before an edit.
+type MessageRevision struct {
 	ID        int       `json:"id" gorm:"primaryKey"`
-	ChatID    int       `json:"chat_id" gorm:"not null;constraint:OnDelete:CASCADE"`
-	Text      string    `json:"text" gorm:"type:text;not null" validate:"required,min=1,max=5000"`
+	MessageID int       `json:"message_id" gorm:"not null;constraint:OnDelete:CASCADE"`
+	Text      string    `json:"text" gorm:"type:text;not null"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
@@ -0,0 +1,16 @@
+package models
+
+// MaxMessageBatch bounds how many messages one batch may import.
+const MaxMessageBatch = 1000
+
+type MessageBatchRequest struct {
+	Messages []*Message `json:"messages"`
+}
+
+// MessageBatchResult is the outcome of the message at Index of a batch:
+// either the stored message or the error that kept it out.
+type MessageBatchResult struct {
+	Index   int
+	Message *Message
+	Err     error
+}
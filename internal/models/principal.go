@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodJWT    = "jwt"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Method string `json:"method"`
+}
+
+// APIKey is a static credential. Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID        int        `json:"id" gorm:"primaryKey"`
+	Name      string     `json:"name" gorm:"type:varchar(255);not null"`
+	KeyHash   string     `json:"-" gorm:"type:char(64);not null;unique"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
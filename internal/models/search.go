@@ -0,0 +1,21 @@
+package models
+
+type SearchQuery struct {
+	Query    string
+	ChatID   int    // zero searches across all chats
+	MemberID string // when set, only chats the user is a member of
+	Limit    int
+	Cursor   *SearchCursor
+}
+
+type SearchResult struct {
+	Message *Message `json:"message"`
+	Rank    float64  `json:"rank"`
+	Snippet string   `json:"snippet"`
+}
+
+type SearchResponse struct {
+	Results    []*SearchResult `json:"results"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	HasMore    bool            `json:"has_more"`
+}
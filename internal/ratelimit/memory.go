@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory keeps buckets in process memory. Each instance limits on its own,
+// so behind a load balancer a client gets the limit once per instance.
+type Memory struct {
+	bucket bucket
+	now    func() time.Time
+
+	mu        sync.Mutex
+	states    map[string]*memoryState
+	lastSweep time.Time
+}
+
+type memoryState struct {
+	tokens  float64
+	updated time.Time
+}
+
+func NewMemory(rate float64, burst int) *Memory {
+	return &Memory{
+		bucket: bucket{rate: rate, burst: burst},
+		now:    time.Now,
+		states: make(map[string]*memoryState),
+	}
+}
+
+func (m *Memory) Allow(ctx context.Context, key string, n int) (Decision, error) {
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	st, ok := m.states[key]
+	if !ok {
+		st = &memoryState{tokens: float64(m.bucket.burst), updated: now}
+		m.states[key] = st
+	}
+	tokens, d := m.bucket.take(st.tokens, st.updated, now, n)
+	st.tokens, st.updated = tokens, now
+	return d, nil
+}
+
+// sweep forgets buckets that have refilled, since a full bucket behaves like
+// a missing one. It walks the map at most once per fill time.
+func (m *Memory) sweep(now time.Time) {
+	fill := m.bucket.fillTime()
+	if now.Sub(m.lastSweep) < fill {
+		return
+	}
+	m.lastSweep = now
+	for key, st := range m.states {
+		if now.Sub(st.updated) >= fill {
+			delete(m.states, key)
+		}
+	}
+}
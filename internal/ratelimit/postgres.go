@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"TestHitalent/pkg/logger"
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Postgres keeps buckets in the rate_limit_buckets table, so every instance
+// behind a load balancer draws from the same bucket. Time is taken from the
+// database clock, so instance clock skew does not mint tokens.
+type Postgres struct {
+	db     *gorm.DB
+	bucket bucket
+
+	// lastSweep is the unix nano time this instance last deleted full buckets.
+	lastSweep atomic.Int64
+}
+
+type postgresState struct {
+	Tokens    float64
+	UpdatedAt time.Time
+	Now       time.Time
+}
+
+func NewPostgres(db *gorm.DB, rate float64, burst int) *Postgres {
+	return &Postgres{
+		db:     db,
+		bucket: bucket{rate: rate, burst: burst},
+	}
+}
+
+func (p *Postgres) Allow(ctx context.Context, key string, n int) (Decision, error) {
+	var d Decision
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO rate_limit_buckets (key, tokens, updated_at) VALUES (?, ?, now())
+			ON CONFLICT (key) DO NOTHING`, key, p.bucket.burst).Error
+		if err != nil {
+			return err
+		}
+
+		var st postgresState
+		err = tx.Raw(`SELECT tokens, updated_at, now() AS now FROM rate_limit_buckets WHERE key = ? FOR UPDATE`, key).
+			Scan(&st).Error
+		if err != nil {
+			return err
+		}
+
+		var tokens float64
+		tokens, d = p.bucket.take(st.Tokens, st.UpdatedAt, st.Now, n)
+		return tx.Exec(`UPDATE rate_limit_buckets SET tokens = ?, updated_at = ? WHERE key = ?`, tokens, st.Now, key).Error
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+
+	p.sweep(ctx)
+	return d, nil
+}
+
+// sweep deletes buckets that have refilled, at most once per fill time per
+// instance. A failed sweep is retried on the next one.
+func (p *Postgres) sweep(ctx context.Context) {
+	fill := p.bucket.fillTime()
+	now := time.Now().UnixNano()
+	last := p.lastSweep.Load()
+	if now-last < int64(fill) || !p.lastSweep.CompareAndSwap(last, now) {
+		return
+	}
+
+	err := p.db.WithContext(ctx).
+		Exec(`DELETE FROM rate_limit_buckets WHERE updated_at < now() - make_interval(secs => ?)`, fill.Seconds()).Error
+	if err != nil {
+		logger.GetLoggerFromCtx(ctx).Error("rate limit sweep failed", zap.Error(err))
+	}
+}
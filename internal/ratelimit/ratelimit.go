@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+
+	KeyByPrincipal = "principal"
+	KeyByIP        = "ip"
+)
+
+// Config sets how fast a client may post messages. Each client gets a bucket
+// of Burst tokens refilled at Rate tokens per second; a message takes one.
+type Config struct {
+	Enabled bool    `yaml:"rate_limit_enabled" env:"RATE_LIMIT_ENABLED" env-default:"false"`
+	Backend string  `yaml:"rate_limit_backend" env:"RATE_LIMIT_BACKEND" env-default:"memory"`
+	Rate    float64 `yaml:"rate_limit_rate" env:"RATE_LIMIT_RATE" env-default:"1"`
+	Burst   int     `yaml:"rate_limit_burst" env:"RATE_LIMIT_BURST" env-default:"10"`
+	KeyBy   string  `yaml:"rate_limit_key" env:"RATE_LIMIT_KEY" env-default:"principal"`
+	PerChat bool    `yaml:"rate_limit_per_chat" env:"RATE_LIMIT_PER_CHAT" env-default:"false"`
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.Backend != BackendMemory && c.Backend != BackendPostgres {
+		errs = append(errs, fmt.Errorf("rate limit backend must be %q or %q, got %q", BackendMemory, BackendPostgres, c.Backend))
+	}
+	if c.KeyBy != KeyByPrincipal && c.KeyBy != KeyByIP {
+		errs = append(errs, fmt.Errorf("rate limit key must be %q or %q, got %q", KeyByPrincipal, KeyByIP, c.KeyBy))
+	}
+	if c.Rate <= 0 {
+		errs = append(errs, errors.New("rate limit rate must be positive"))
+	}
+	if c.Burst < 1 {
+		errs = append(errs, errors.New("rate limit burst must be at least 1"))
+	}
+	return errors.Join(errs...)
+}
+
+// Decision is the outcome of taking tokens from a bucket.
+type Decision struct {
+	Allowed bool
+	// Limit is the bucket size and Remaining the whole tokens left in it.
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until there are enough tokens, zero when Allowed
+	// or when more were asked for than the bucket holds.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Limiter takes n tokens from the bucket of key, all or none.
+type Limiter interface {
+	Allow(ctx context.Context, key string, n int) (Decision, error)
+}
+
+// bucket is the refill policy both backends apply to the state they store.
+type bucket struct {
+	rate  float64
+	burst int
+}
+
+// take refills tokens last updated at last up to now and takes n if there are
+// that many. It returns the tokens left.
+func (b bucket) take(tokens float64, last, now time.Time, n int) (float64, Decision) {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens += elapsed * b.rate
+	}
+	tokens = math.Min(tokens, float64(b.burst))
+
+	d := Decision{Limit: b.burst}
+	if tokens >= float64(n) {
+		tokens -= float64(n)
+		d.Allowed = true
+	} else if n <= b.burst {
+		d.RetryAfter = b.duration(float64(n) - tokens)
+	}
+	d.Remaining = int(math.Floor(tokens))
+	d.Reset = b.duration(float64(b.burst) - tokens)
+	return tokens, d
+}
+
+// fillTime is how long an empty bucket takes to fill. A bucket untouched for
+// that long is full and may be forgotten.
+func (b bucket) fillTime() time.Duration {
+	return b.duration(float64(b.burst))
+}
+
+func (b bucket) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / b.rate * float64(time.Second))
+}
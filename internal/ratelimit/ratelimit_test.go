@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_Allow(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemory(2, 3)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// A new client starts with a full bucket.
+	for remaining := 2; remaining >= 0; remaining-- {
+		d, err := m.Allow(ctx, "a", 1)
+		require.NoError(t, err)
+		require.True(t, d.Allowed)
+		require.Equal(t, 3, d.Limit)
+		require.Equal(t, remaining, d.Remaining)
+	}
+
+	d, err := m.Allow(ctx, "a", 1)
+	require.NoError(t, err)
+	require.False(t, d.Allowed)
+	require.Equal(t, 0, d.Remaining)
+	require.Equal(t, 500*time.Millisecond, d.RetryAfter)
+	require.Equal(t, 1500*time.Millisecond, d.Reset)
+
+	// Other keys have their own buckets.
+	d, err = m.Allow(ctx, "b", 1)
+	require.NoError(t, err)
+	require.True(t, d.Allowed)
+
+	// Tokens refill at the configured rate.
+	now = now.Add(500 * time.Millisecond)
+	d, err = m.Allow(ctx, "a", 1)
+	require.NoError(t, err)
+	require.True(t, d.Allowed)
+	require.Zero(t, d.RetryAfter)
+
+	// Never beyond the bucket size.
+	now = now.Add(time.Hour)
+	d, err = m.Allow(ctx, "a", 1)
+	require.NoError(t, err)
+	require.Equal(t, 2, d.Remaining)
+}
+
+func TestMemory_AllowN(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemory(2, 3)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	d, err := m.Allow(ctx, "a", 2)
+	require.NoError(t, err)
+	require.True(t, d.Allowed)
+	require.Equal(t, 1, d.Remaining)
+
+	// Not enough tokens: none are taken.
+	d, err = m.Allow(ctx, "a", 2)
+	require.NoError(t, err)
+	require.False(t, d.Allowed)
+	require.Equal(t, 1, d.Remaining)
+	require.Equal(t, 500*time.Millisecond, d.RetryAfter)
+
+	// More than the bucket holds never succeeds, so there is nothing to wait for.
+	now = now.Add(time.Hour)
+	d, err = m.Allow(ctx, "a", 4)
+	require.NoError(t, err)
+	require.False(t, d.Allowed)
+	require.Equal(t, 3, d.Remaining)
+	require.Zero(t, d.RetryAfter)
+}
+
+func TestMemory_SweepsFullBuckets(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemory(1, 2)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := m.Allow(ctx, "idle", 1)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Second)
+	_, err = m.Allow(ctx, "active", 1)
+	require.NoError(t, err)
+
+	require.Len(t, m.states, 1)
+	require.Contains(t, m.states, "active")
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := Config{Backend: BackendMemory, KeyBy: KeyByPrincipal, Rate: 1, Burst: 10}
+	require.NoError(t, valid.Validate())
+
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+	}{
+		{"unknown backend", func(c *Config) { c.Backend = "redis" }},
+		{"unknown key", func(c *Config) { c.KeyBy = "header" }},
+		{"zero rate", func(c *Config) { c.Rate = 0 }},
+		{"zero burst", func(c *Config) { c.Burst = 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.mutate(&cfg)
+			require.Error(t, cfg.Validate())
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package repository_test
+
+import (
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/repository"
+	"TestHitalent/internal/service"
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// benchmarkMessages is how many messages the benchmarked chat holds, more
+// than one page.
+const benchmarkMessages = 200
+
+// BenchmarkRepositories compares the GORM and pgx repositories on the
+// queries every request to a chat makes. It needs the database
+// postgresDSNEnv names, like the contract suite.
+func BenchmarkRepositories(b *testing.B) {
+	db := openPostgres(b)
+	pool := openPgxPool(b)
+
+	repos := []struct {
+		name string
+		repo service.HiTalentRepositoryInterface
+	}{
+		{"gorm", repository.NewHiTalentRepository(db)},
+		{"pgx", repository.NewPgxRepository(db, pool)},
+	}
+
+	for _, r := range repos {
+		b.Run(r.name, func(b *testing.B) {
+			truncatePostgres(b, db)
+			chatId := seedBenchmarkChat(b, r.repo)
+
+			b.Run("GetChat", func(b *testing.B) {
+				ctx := context.Background()
+				for b.Loop() {
+					if _, err := r.repo.GetChat(ctx, chatId, &models.MessagesQuery{Limit: 50}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+
+			b.Run("CreateMessage", func(b *testing.B) {
+				ctx := context.Background()
+				for b.Loop() {
+					message := &models.Message{SenderID: "alice", SenderName: "Alice", Text: "Hello"}
+					if _, err := r.repo.CreateMessage(ctx, chatId, message); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+
+			b.Run("GetChatRole", func(b *testing.B) {
+				ctx := context.Background()
+				for b.Loop() {
+					if _, err := r.repo.GetChatRole(ctx, chatId, "alice"); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func seedBenchmarkChat(b *testing.B, repo service.HiTalentRepositoryInterface) int {
+	ctx := context.Background()
+
+	chat, err := repo.CreateChat(ctx, &models.Chat{Title: "Benchmark"}, "alice")
+	require.NoError(b, err)
+
+	for i := range benchmarkMessages {
+		message := &models.Message{SenderID: "alice", SenderName: "Alice", Text: "Message " + strconv.Itoa(i)}
+		_, err := repo.CreateMessage(ctx, chat.ID, message)
+		require.NoError(b, err)
+	}
+	return chat.ID
+}
@@ -0,0 +1,575 @@
+package repository_test
+
+import (
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/repository"
+	"TestHitalent/internal/service"
+	"TestHitalent/pkg/sqlite"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// postgresDSNEnv names the database the contract suite runs against in
+// addition to the in-memory repository. The database is truncated.
+const postgresDSNEnv = "HITALENT_TEST_POSTGRES_DSN"
+
+// contractOperator acts as the owner of every chat, so the service can be
+// run over a repository without setting up members.
+var contractOperator = &models.Principal{ID: "api_key:1", Name: "ci", Method: models.AuthMethodAPIKey}
+
+type contractRepository interface {
+	service.HiTalentRepositoryInterface
+	CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, name string) error
+}
+
+func TestMemoryRepository_Contract(t *testing.T) {
+	testRepositoryContract(t, func(t *testing.T) contractRepository {
+		return repository.NewMemoryRepository(nil)
+	})
+}
+
+func TestSQLiteRepository_Contract(t *testing.T) {
+	testRepositoryContract(t, func(t *testing.T) contractRepository {
+		db, err := sqlite.New(sqlite.Config{Path: filepath.Join(t.TempDir(), "hitalent.db")})
+		require.NoError(t, err)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		t.Cleanup(func() { sqlDB.Close() })
+
+		require.NoError(t, goose.SetDialect("sqlite3"))
+		require.NoError(t, goose.Up(sqlDB, "../../migrations/sqlite"))
+		return repository.NewSQLiteRepository(db, nil)
+	})
+}
+
+func TestHiTalentRepository_Contract(t *testing.T) {
+	db := openPostgres(t)
+
+	testRepositoryContract(t, func(t *testing.T) contractRepository {
+		truncatePostgres(t, db)
+		return repository.NewHiTalentRepository(db)
+	})
+}
+
+func TestPgxRepository_Contract(t *testing.T) {
+	db := openPostgres(t)
+	pool := openPgxPool(t)
+
+	testRepositoryContract(t, func(t *testing.T) contractRepository {
+		truncatePostgres(t, db)
+		return repository.NewPgxRepository(db, pool)
+	})
+}
+
+// openPostgres connects to the database postgresDSNEnv names and migrates
+// it, or skips when it is not set.
+func openPostgres(tb testing.TB) *gorm.DB {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		tb.Skipf("%s is not set", postgresDSNEnv)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+		NowFunc: func() time.Time {
+			return time.Now().UTC()
+		},
+	})
+	require.NoError(tb, err)
+	sqlDB, err := db.DB()
+	require.NoError(tb, err)
+	tb.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(tb, goose.SetDialect("postgres"))
+	require.NoError(tb, goose.Up(sqlDB, "../../migrations"))
+	return db
+}
+
+// openPgxPool connects the pool PgxRepository uses to the database
+// openPostgres migrated.
+func openPgxPool(tb testing.TB) *pgxpool.Pool {
+	config, err := pgxpool.ParseConfig(os.Getenv(postgresDSNEnv))
+	require.NoError(tb, err)
+	config.AfterConnect = repository.PreparePgxStatements
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	require.NoError(tb, err)
+	tb.Cleanup(pool.Close)
+	return pool
+}
+
+func truncatePostgres(tb testing.TB, db *gorm.DB) {
+	require.NoError(tb, db.Exec("TRUNCATE chats, messages, message_revisions, chat_members, api_keys RESTART IDENTITY CASCADE").Error)
+}
+
+// testRepositoryContract checks the behaviour the service relies on. Every
+// subtest gets an empty repository from newRepo.
+func testRepositoryContract(t *testing.T, newRepo func(t *testing.T) contractRepository) {
+	ctx := context.Background()
+
+	createChat := func(t *testing.T, repo contractRepository, title string, ownerId string) *models.Chat {
+		chat, err := repo.CreateChat(ctx, &models.Chat{Title: title}, ownerId)
+		require.NoError(t, err)
+		return chat
+	}
+	createMessage := func(t *testing.T, repo contractRepository, chatId int, senderId string, text string) *models.Message {
+		message, err := repo.CreateMessage(ctx, chatId, &models.Message{SenderID: senderId, Text: text})
+		require.NoError(t, err)
+		return message
+	}
+	messageIds := func(messages []*models.Message) []int {
+		ids := []int{}
+		for _, m := range messages {
+			ids = append(ids, m.ID)
+		}
+		return ids
+	}
+
+	t.Run("missing chat", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetChat(ctx, 1, &models.MessagesQuery{Limit: 20})
+		require.ErrorIs(t, err, suberrors.ErrChatNotFound)
+		require.ErrorIs(t, repo.ChatExists(ctx, 1), suberrors.ErrChatNotFound)
+		_, err = repo.UpdateChat(ctx, 1, "title", 0)
+		require.ErrorIs(t, err, suberrors.ErrChatNotFound)
+		require.ErrorIs(t, repo.DeleteChat(ctx, 1), suberrors.ErrChatNotFound)
+		_, err = repo.CreateMessage(ctx, 1, &models.Message{Text: "hello"})
+		require.ErrorIs(t, err, suberrors.ErrChatNotFound)
+		_, err = repo.ListMessagesAfter(ctx, 1, 0, 10)
+		require.ErrorIs(t, err, suberrors.ErrChatNotFound)
+		_, err = repo.Search(ctx, &models.SearchQuery{Query: "hello", ChatID: 1, Limit: 10})
+		require.ErrorIs(t, err, suberrors.ErrChatNotFound)
+		_, err = repo.GetChatRole(ctx, 1, "alice")
+		require.ErrorIs(t, err, suberrors.ErrChatNotFound)
+		_, err = repo.AddChatMember(ctx, &models.ChatMember{ChatID: 1, UserID: "alice", Role: models.ChatRoleMember})
+		require.ErrorIs(t, err, suberrors.ErrChatNotFound)
+	})
+
+	t.Run("create chat", func(t *testing.T) {
+		repo := newRepo(t)
+
+		chat := createChat(t, repo, "General", "alice")
+		require.NotZero(t, chat.ID)
+		require.Equal(t, 1, chat.Version)
+		require.False(t, chat.CreatedAt.IsZero())
+		require.NoError(t, repo.ChatExists(ctx, chat.ID))
+
+		role, err := repo.GetChatRole(ctx, chat.ID, "alice")
+		require.NoError(t, err)
+		require.Equal(t, models.ChatRoleOwner, role)
+
+		got, err := repo.GetChat(ctx, chat.ID, &models.MessagesQuery{Limit: 20})
+		require.NoError(t, err)
+		require.Equal(t, "General", got.Chat.Title)
+		require.Empty(t, got.Messages)
+		require.NotNil(t, got.Messages)
+		require.False(t, got.HasMore)
+	})
+
+	t.Run("create chat with messages", func(t *testing.T) {
+		repo := newRepo(t)
+
+		chat, err := repo.CreateChat(ctx, &models.Chat{
+			Title: "Onboarding",
+			Messages: []*models.Message{
+				{SenderID: "alice", SenderName: "Alice", Text: "Welcome"},
+				{SenderID: "alice", SenderName: "Alice", Text: "Read the rules"},
+			},
+		}, "alice")
+		require.NoError(t, err)
+		require.Len(t, chat.Messages, 2)
+		for _, message := range chat.Messages {
+			require.NotZero(t, message.ID)
+			require.Equal(t, chat.ID, message.ChatID)
+			require.False(t, message.CreatedAt.IsZero())
+		}
+
+		got, err := repo.GetChat(ctx, chat.ID, &models.MessagesQuery{Limit: 20})
+		require.NoError(t, err)
+		require.Equal(t, []int{chat.Messages[1].ID, chat.Messages[0].ID}, messageIds(got.Messages))
+		require.Equal(t, "Read the rules", got.Messages[0].Text)
+		require.Equal(t, "Alice", got.Messages[0].SenderName)
+	})
+
+	t.Run("messages newest first", func(t *testing.T) {
+		repo := newRepo(t)
+		chat := createChat(t, repo, "General", "alice")
+		var ids []int
+		for i := 0; i < 5; i++ {
+			sender := "alice"
+			if i%2 == 1 {
+				sender = "bob"
+			}
+			ids = append(ids, createMessage(t, repo, chat.ID, sender, "message").ID)
+		}
+		byId := map[int]*models.Message{}
+
+		got, err := repo.GetChat(ctx, chat.ID, &models.MessagesQuery{Limit: 3})
+		require.NoError(t, err)
+		require.Equal(t, []int{ids[4], ids[3], ids[2]}, messageIds(got.Messages))
+		require.True(t, got.HasMore)
+		for _, m := range got.Messages {
+			byId[m.ID] = m
+		}
+
+		got, err = repo.GetChat(ctx, chat.ID, &models.MessagesQuery{Limit: 3, Before: models.NewMessageCursor(byId[ids[2]])})
+		require.NoError(t, err)
+		require.Equal(t, []int{ids[1], ids[0]}, messageIds(got.Messages))
+		require.False(t, got.HasMore)
+
+		got, err = repo.GetChat(ctx, chat.ID, &models.MessagesQuery{Limit: 1, After: models.NewMessageCursor(byId[ids[2]])})
+		require.NoError(t, err)
+		require.Equal(t, []int{ids[3]}, messageIds(got.Messages))
+		require.True(t, got.HasMore)
+
+		got, err = repo.GetChat(ctx, chat.ID, &models.MessagesQuery{Limit: 5, SenderID: "bob"})
+		require.NoError(t, err)
+		require.Equal(t, []int{ids[3], ids[1]}, messageIds(got.Messages))
+		require.False(t, got.HasMore)
+
+		after, err := repo.ListMessagesAfter(ctx, chat.ID, ids[1], 2)
+		require.NoError(t, err)
+		require.Equal(t, []int{ids[2], ids[3]}, messageIds(after))
+	})
+
+	t.Run("update chat", func(t *testing.T) {
+		repo := newRepo(t)
+		chat := createChat(t, repo, "General", "alice")
+
+		updated, err := repo.UpdateChat(ctx, chat.ID, "Renamed", 1)
+		require.NoError(t, err)
+		require.Equal(t, "Renamed", updated.Title)
+		require.Equal(t, 2, updated.Version)
+
+		_, err = repo.UpdateChat(ctx, chat.ID, "Stale", 1)
+		require.ErrorIs(t, err, suberrors.ErrChatVersionMismatch)
+
+		updated, err = repo.UpdateChat(ctx, chat.ID, "Unconditional", 0)
+		require.NoError(t, err)
+		require.Equal(t, 3, updated.Version)
+	})
+
+	t.Run("delete chat cascades", func(t *testing.T) {
+		repo := newRepo(t)
+		chat := createChat(t, repo, "General", "alice")
+		other := createChat(t, repo, "Other", "alice")
+		message := createMessage(t, repo, chat.ID, "alice", "hello")
+		_, err := repo.UpdateMessage(ctx, chat.ID, message.ID, "hello again")
+		require.NoError(t, err)
+		kept := createMessage(t, repo, other.ID, "alice", "hello")
+
+		require.NoError(t, repo.DeleteChat(ctx, chat.ID))
+
+		require.ErrorIs(t, repo.ChatExists(ctx, chat.ID), suberrors.ErrChatNotFound)
+		_, err = repo.GetMessage(ctx, chat.ID, message.ID)
+		require.ErrorIs(t, err, suberrors.ErrMessageNotFound)
+		members, err := repo.ListChatMembers(ctx, chat.ID)
+		require.NoError(t, err)
+		require.Empty(t, members)
+		search, err := repo.Search(ctx, &models.SearchQuery{Query: "hello", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, search.Results, 1)
+		require.Equal(t, kept.ID, search.Results[0].Message.ID)
+
+		_, err = repo.GetMessage(ctx, other.ID, kept.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("posting ignores stored fields", func(t *testing.T) {
+		repo := newRepo(t)
+		chat := createChat(t, repo, "General", "alice")
+		srv := service.NewHiTalentService(repo, nil, nil)
+
+		at := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+		posted, err := srv.CreateMessage(ctx, contractOperator, strconv.Itoa(chat.ID),
+			&models.Message{ID: 999, ChatID: chat.ID + 1, Text: "hello", CreatedAt: at, EditedAt: &at, DeletedAt: &at})
+		require.NoError(t, err)
+
+		stored, err := repo.GetMessage(ctx, chat.ID, posted.ID)
+		require.NoError(t, err)
+		require.NotEqual(t, 999, stored.ID)
+		require.Equal(t, chat.ID, stored.ChatID)
+		require.True(t, stored.CreatedAt.After(at))
+		require.Nil(t, stored.EditedAt)
+		require.Nil(t, stored.DeletedAt)
+	})
+
+	t.Run("creating a chat ignores stored fields of its messages", func(t *testing.T) {
+		repo := newRepo(t)
+		srv := service.NewHiTalentService(repo, nil, nil)
+
+		at := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+		chat, err := srv.CreateChat(ctx, contractOperator, &models.Chat{
+			Title:    "Onboarding",
+			Messages: []*models.Message{{ID: 999, ChatID: 999, Text: "Welcome", CreatedAt: at, EditedAt: &at, DeletedAt: &at}},
+		})
+		require.NoError(t, err)
+
+		got, err := repo.GetChat(ctx, chat.ID, &models.MessagesQuery{Limit: 20})
+		require.NoError(t, err)
+		require.Len(t, got.Messages, 1)
+		stored := got.Messages[0]
+		require.NotEqual(t, 999, stored.ID)
+		require.Equal(t, chat.ID, stored.ChatID)
+		require.True(t, stored.CreatedAt.After(at))
+		require.Nil(t, stored.EditedAt)
+		require.Nil(t, stored.DeletedAt)
+	})
+
+	t.Run("importing ignores stored fields", func(t *testing.T) {
+		repo := newRepo(t)
+		chat := createChat(t, repo, "General", "alice")
+		srv := service.NewHiTalentService(repo, nil, nil)
+
+		// The same export imported twice reuses its ids.
+		sentAt := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+		for range 2 {
+			results, err := srv.CreateMessageBatch(ctx, contractOperator, strconv.Itoa(chat.ID), []*models.Message{
+				{ID: 999, ChatID: chat.ID + 1, Text: "exported", CreatedAt: sentAt, EditedAt: &sentAt, DeletedAt: &sentAt},
+			})
+			require.NoError(t, err)
+			require.NoError(t, results[0].Err)
+		}
+
+		got, err := repo.GetChat(ctx, chat.ID, &models.MessagesQuery{Limit: 20})
+		require.NoError(t, err)
+		require.Len(t, got.Messages, 2)
+		for _, stored := range got.Messages {
+			require.NotEqual(t, 999, stored.ID)
+			require.Equal(t, chat.ID, stored.ChatID)
+			require.Equal(t, "exported", stored.Text)
+			require.True(t, sentAt.Equal(stored.CreatedAt))
+			require.Nil(t, stored.EditedAt)
+			require.Nil(t, stored.DeletedAt)
+		}
+	})
+
+	t.Run("create messages", func(t *testing.T) {
+		repo := newRepo(t)
+		chat := createChat(t, repo, "General", "alice")
+		latest := createMessage(t, repo, chat.ID, "alice", "latest")
+
+		_, err := repo.CreateMessages(ctx, chat.ID+1, []*models.Message{{Text: "lost"}})
+		require.ErrorIs(t, err, suberrors.ErrChatNotFound)
+
+		sentAt := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+		imported, err := repo.CreateMessages(ctx, chat.ID, []*models.Message{
+			{SenderID: "bob", SenderName: "Bob", Text: "first", CreatedAt: sentAt},
+			{SenderID: "bob", SenderName: "Bob", Text: "second", CreatedAt: sentAt.Add(time.Minute)},
+			{SenderID: "bob", Text: "undated"},
+		})
+		require.NoError(t, err)
+		require.Len(t, imported, 3)
+		for _, m := range imported {
+			require.NotZero(t, m.ID)
+			require.Equal(t, chat.ID, m.ChatID)
+		}
+		require.True(t, sentAt.Equal(imported[0].CreatedAt))
+		require.False(t, imported[2].CreatedAt.IsZero())
+
+		// Messages sort by the time they were sent, not by when they were
+		// imported.
+		got, err := repo.GetChat(ctx, chat.ID, &models.MessagesQuery{Limit: 20})
+		require.NoError(t, err)
+		require.Equal(t, []int{imported[2].ID, latest.ID, imported[1].ID, imported[0].ID}, messageIds(got.Messages))
+		require.True(t, sentAt.Equal(got.Messages[3].CreatedAt))
+	})
+
+	t.Run("edit and delete messages", func(t *testing.T) {
+		repo := newRepo(t)
+		chat := createChat(t, repo, "General", "alice")
+		other := createChat(t, repo, "Other", "alice")
+		message := createMessage(t, repo, chat.ID, "alice", "hello")
+
+		_, err := repo.GetMessage(ctx, other.ID, message.ID)
+		require.ErrorIs(t, err, suberrors.ErrMessageNotFound)
+		_, err = repo.UpdateMessage(ctx, other.ID, message.ID, "moved")
+		require.ErrorIs(t, err, suberrors.ErrMessageNotFound)
+
+		edited, err := repo.UpdateMessage(ctx, chat.ID, message.ID, "hello, edited")
+		require.NoError(t, err)
+		require.Equal(t, "hello, edited", edited.Text)
+		require.NotNil(t, edited.EditedAt)
+
+		require.ErrorIs(t, repo.DeleteMessage(ctx, other.ID, message.ID), suberrors.ErrMessageNotFound)
+		require.NoError(t, repo.DeleteMessage(ctx, chat.ID, message.ID))
+		require.ErrorIs(t, repo.DeleteMessage(ctx, chat.ID, message.ID), suberrors.ErrMessageNotFound)
+		_, err = repo.UpdateMessage(ctx, chat.ID, message.ID, "too late")
+		require.ErrorIs(t, err, suberrors.ErrMessageNotFound)
+
+		// Deleted messages stay in the history as tombstones.
+		got, err := repo.GetChat(ctx, chat.ID, &models.MessagesQuery{Limit: 20})
+		require.NoError(t, err)
+		require.Len(t, got.Messages, 1)
+		require.NotNil(t, got.Messages[0].DeletedAt)
+	})
+
+	t.Run("list chats", func(t *testing.T) {
+		repo := newRepo(t)
+		general := createChat(t, repo, "General", "alice")
+		random := createChat(t, repo, "Random talk", "bob")
+		support := createChat(t, repo, "Support", "alice")
+		_, err := repo.AddChatMember(ctx, &models.ChatMember{ChatID: random.ID, UserID: "alice", Role: models.ChatRoleMember})
+		require.NoError(t, err)
+
+		chatIds := func(chats []*models.Chat) []int {
+			ids := []int{}
+			for _, c := range chats {
+				ids = append(ids, c.ID)
+			}
+			return ids
+		}
+		list := func(query *models.ChatsQuery) *models.ChatListResponse {
+			if query.Limit == 0 {
+				query.Limit = 20
+			}
+			if query.Sort == "" {
+				query.Sort = models.ChatSortCreatedAt
+			}
+			if query.Order == "" {
+				query.Order = models.SortOrderDesc
+			}
+			got, err := repo.ListChats(ctx, query)
+			require.NoError(t, err)
+			return got
+		}
+
+		got := list(&models.ChatsQuery{Limit: 2})
+		require.Equal(t, []int{support.ID, random.ID}, chatIds(got.Chats))
+		require.True(t, got.HasMore)
+		last := got.Chats[1]
+		got = list(&models.ChatsQuery{Limit: 2, Cursor: &models.ChatCursor{SortValue: last.CreatedAt, ID: last.ID}})
+		require.Equal(t, []int{general.ID}, chatIds(got.Chats))
+		require.False(t, got.HasMore)
+
+		got = list(&models.ChatsQuery{Order: models.SortOrderAsc})
+		require.Equal(t, []int{general.ID, random.ID, support.ID}, chatIds(got.Chats))
+		// Compare with stored timestamps, which may be less precise than Go's.
+		randomCreated, supportCreated := got.Chats[1].CreatedAt, got.Chats[2].CreatedAt
+
+		got = list(&models.ChatsQuery{MemberID: "bob"})
+		require.Equal(t, []int{random.ID}, chatIds(got.Chats))
+		got = list(&models.ChatsQuery{Title: "TALK"})
+		require.Equal(t, []int{random.ID}, chatIds(got.Chats))
+		got = list(&models.ChatsQuery{CreatedFrom: &randomCreated, CreatedTo: &randomCreated})
+		require.Equal(t, []int{random.ID}, chatIds(got.Chats))
+
+		// Timestamps must differ for the activity order to be determined.
+		time.Sleep(time.Millisecond)
+		message := createMessage(t, repo, general.ID, "alice", "hello")
+		message, err = repo.GetMessage(ctx, general.ID, message.ID)
+		require.NoError(t, err)
+		got = list(&models.ChatsQuery{Sort: models.ChatSortLastActivity})
+		require.Equal(t, []int{general.ID, support.ID, random.ID}, chatIds(got.Chats))
+		require.True(t, got.Chats[0].LastActivityAt.Equal(message.CreatedAt))
+		require.True(t, got.Chats[1].LastActivityAt.Equal(supportCreated))
+	})
+
+	t.Run("search", func(t *testing.T) {
+		repo := newRepo(t)
+		general := createChat(t, repo, "General", "alice")
+		private := createChat(t, repo, "Private", "bob")
+		deploy := createMessage(t, repo, general.ID, "alice", "Deploy at noon")
+		rollback := createMessage(t, repo, general.ID, "alice", "deploy rollback <b>now</b>")
+		deleted := createMessage(t, repo, general.ID, "alice", "deploy tomorrow")
+		require.NoError(t, repo.DeleteMessage(ctx, general.ID, deleted.ID))
+		hidden := createMessage(t, repo, private.ID, "bob", "deploy in private")
+
+		search := func(query *models.SearchQuery) []int {
+			query.Limit = 10
+			got, err := repo.Search(ctx, query)
+			require.NoError(t, err)
+			ids := []int{}
+			for _, r := range got.Results {
+				ids = append(ids, r.Message.ID)
+			}
+			return ids
+		}
+
+		require.ElementsMatch(t, []int{deploy.ID, rollback.ID, hidden.ID}, search(&models.SearchQuery{Query: "deploy"}))
+		require.ElementsMatch(t, []int{deploy.ID, rollback.ID}, search(&models.SearchQuery{Query: "deploy", ChatID: general.ID}))
+		require.ElementsMatch(t, []int{deploy.ID, rollback.ID}, search(&models.SearchQuery{Query: "deploy", MemberID: "alice"}))
+		require.Equal(t, []int{rollback.ID}, search(&models.SearchQuery{Query: "deploy rollback"}))
+		require.ElementsMatch(t, []int{deploy.ID, hidden.ID}, search(&models.SearchQuery{Query: "deploy -rollback"}))
+		require.Empty(t, search(&models.SearchQuery{Query: "release"}))
+
+		got, err := repo.Search(ctx, &models.SearchQuery{Query: "rollback", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, got.Results, 1)
+		require.Contains(t, got.Results[0].Snippet, "<mark>rollback</mark>")
+		require.NotContains(t, got.Results[0].Snippet, "<b>")
+
+		got, err = repo.Search(ctx, &models.SearchQuery{Query: "deploy", Limit: 2})
+		require.NoError(t, err)
+		require.True(t, got.HasMore)
+		got, err = repo.Search(ctx, &models.SearchQuery{Query: "deploy", Limit: 2, Cursor: &models.SearchCursor{Offset: 2}})
+		require.NoError(t, err)
+		require.Len(t, got.Results, 1)
+		require.False(t, got.HasMore)
+	})
+
+	t.Run("members", func(t *testing.T) {
+		repo := newRepo(t)
+		chat := createChat(t, repo, "General", "alice")
+
+		member, err := repo.AddChatMember(ctx, &models.ChatMember{ChatID: chat.ID, UserID: "bob", Role: models.ChatRoleReadOnly})
+		require.NoError(t, err)
+		added := member.CreatedAt
+		member, err = repo.AddChatMember(ctx, &models.ChatMember{ChatID: chat.ID, UserID: "bob", Role: models.ChatRoleOwner})
+		require.NoError(t, err)
+		require.Equal(t, models.ChatRoleOwner, member.Role)
+		require.True(t, member.CreatedAt.Equal(added))
+
+		members, err := repo.ListChatMembers(ctx, chat.ID)
+		require.NoError(t, err)
+		require.Len(t, members, 2)
+		require.Equal(t, "alice", members[0].UserID)
+		require.Equal(t, "bob", members[1].UserID)
+
+		owners, err := repo.CountChatOwners(ctx, chat.ID)
+		require.NoError(t, err)
+		require.Equal(t, 2, owners)
+
+		require.NoError(t, repo.RemoveChatMember(ctx, chat.ID, "bob"))
+		require.ErrorIs(t, repo.RemoveChatMember(ctx, chat.ID, "bob"), suberrors.ErrMemberNotFound)
+		role, err := repo.GetChatRole(ctx, chat.ID, "bob")
+		require.NoError(t, err)
+		require.Empty(t, role)
+	})
+
+	t.Run("api keys", func(t *testing.T) {
+		repo := newRepo(t)
+
+		key, err := repo.CreateAPIKey(ctx, &models.APIKey{Name: "ci", KeyHash: "hash-1"})
+		require.NoError(t, err)
+		require.NotZero(t, key.ID)
+		_, err = repo.CreateAPIKey(ctx, &models.APIKey{Name: "ci", KeyHash: "hash-2"})
+		require.Error(t, err)
+
+		got, err := repo.GetAPIKey(ctx, "hash-1")
+		require.NoError(t, err)
+		require.Equal(t, "ci", got.Name)
+
+		require.NoError(t, repo.RevokeAPIKey(ctx, "ci"))
+		require.ErrorIs(t, repo.RevokeAPIKey(ctx, "ci"), suberrors.ErrAPIKeyNotFound)
+		_, err = repo.GetAPIKey(ctx, "hash-1")
+		require.ErrorIs(t, err, suberrors.ErrAPIKeyNotFound)
+
+		_, err = repo.CreateAPIKey(ctx, &models.APIKey{Name: "ci", KeyHash: "hash-2"})
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,608 @@
+package repository
+
+import (
+	"TestHitalent/internal/models"
+	"TestHitalent/pkg/suberrors"
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventPublisher delivers chat events to subscribers of this process.
+type EventPublisher interface {
+	Publish(event *models.Event)
+}
+
+// MemoryRepository keeps everything in process memory, for running the
+// service locally and in tests without Postgres. It follows the semantics of
+// HiTalentRepository, including cascading deletes and ordering; full-text
+// search is approximated by matching whole words. Events are published to
+// this process only, so it suits a single instance.
+type MemoryRepository struct {
+	publisher EventPublisher
+	now       func() time.Time
+
+	mu           sync.RWMutex
+	chats        map[int]*models.Chat
+	chatMessages map[int][]*models.Message
+	revisions    map[int][]*models.MessageRevision
+	members      map[int]map[string]*models.ChatMember
+	apiKeys      map[int]*models.APIKey
+	lastID       struct{ chat, message, revision, apiKey int }
+}
+
+func NewMemoryRepository(publisher EventPublisher) *MemoryRepository {
+	return &MemoryRepository{
+		publisher: publisher,
+		// Postgres keeps timestamps to the microsecond; cursors must compare
+		// the same way on both backends.
+		now: func() time.Time {
+			return time.Now().UTC().Truncate(time.Microsecond)
+		},
+		chats:        make(map[int]*models.Chat),
+		chatMessages: make(map[int][]*models.Message),
+		revisions:    make(map[int][]*models.MessageRevision),
+		members:      make(map[int]map[string]*models.ChatMember),
+		apiKeys:      make(map[int]*models.APIKey),
+	}
+}
+
+// CreateChat stores a chat together with its owner membership and initial
+// messages.
+func (r *MemoryRepository) CreateChat(ctx context.Context, chat *models.Chat, ownerId string) (*models.Chat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.lastID.chat++
+	chat.ID = r.lastID.chat
+	chat.CreatedAt = now
+	chat.UpdatedAt = now
+	if chat.Version == 0 {
+		chat.Version = 1
+	}
+	stored := *chat
+	stored.LastActivityAt = nil
+	stored.Messages = nil
+	r.chats[chat.ID] = &stored
+
+	r.members[chat.ID] = map[string]*models.ChatMember{
+		ownerId: {ChatID: chat.ID, UserID: ownerId, Role: models.ChatRoleOwner, CreatedAt: now},
+	}
+
+	for _, message := range chat.Messages {
+		r.lastID.message++
+		message.ID = r.lastID.message
+		message.ChatID = chat.ID
+		message.CreatedAt = now
+		r.chatMessages[chat.ID] = append(r.chatMessages[chat.ID], copyMessage(message))
+	}
+
+	return chat, nil
+}
+
+// ChatExists reports ErrChatNotFound for a missing chat.
+func (r *MemoryRepository) ChatExists(ctx context.Context, chatId int) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.chats[chatId]; !ok {
+		return suberrors.ErrChatNotFound
+	}
+	return nil
+}
+
+func (r *MemoryRepository) GetChat(ctx context.Context, chatId int, query *models.MessagesQuery) (*models.ChatAndMessagesResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chat, ok := r.chats[chatId]
+	if !ok {
+		return nil, suberrors.ErrChatNotFound
+	}
+
+	messages := []*models.Message{}
+	for _, m := range r.chatMessages[chatId] {
+		if query.SenderID != "" && m.SenderID != query.SenderID {
+			continue
+		}
+		switch {
+		case query.Before != nil && compareMessage(m, query.Before.CreatedAt, query.Before.ID) >= 0:
+			continue
+		case query.After != nil && compareMessage(m, query.After.CreatedAt, query.After.ID) <= 0:
+			continue
+		}
+		messages = append(messages, copyMessage(m))
+	}
+
+	// Newest first; a page after a cursor is taken oldest first so it starts
+	// right at the cursor, then flipped back.
+	slices.SortFunc(messages, func(a, b *models.Message) int {
+		return -compareMessage(a, b.CreatedAt, b.ID)
+	})
+	if query.After != nil {
+		slices.Reverse(messages)
+	}
+
+	hasMore := len(messages) > query.Limit
+	if hasMore {
+		messages = messages[:query.Limit]
+	}
+
+	if query.After != nil {
+		slices.Reverse(messages)
+	}
+
+	stored := *chat
+	return &models.ChatAndMessagesResponse{
+		Chat:     &stored,
+		Messages: messages,
+		HasMore:  hasMore,
+	}, nil
+}
+
+func (r *MemoryRepository) ListChats(ctx context.Context, query *models.ChatsQuery) (*models.ChatListResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sortValue := func(c *models.Chat) time.Time {
+		if query.Sort == models.ChatSortLastActivity {
+			return *c.LastActivityAt
+		}
+		return c.CreatedAt
+	}
+	direction := -1
+	if query.Order == models.SortOrderAsc {
+		direction = 1
+	}
+
+	chats := []*models.Chat{}
+	for _, c := range r.chats {
+		if query.MemberID != "" {
+			if _, ok := r.members[c.ID][query.MemberID]; !ok {
+				continue
+			}
+		}
+		if query.Title != "" && !strings.Contains(strings.ToLower(c.Title), strings.ToLower(query.Title)) {
+			continue
+		}
+		if query.CreatedFrom != nil && c.CreatedAt.Before(*query.CreatedFrom) {
+			continue
+		}
+		if query.CreatedTo != nil && c.CreatedAt.After(*query.CreatedTo) {
+			continue
+		}
+
+		chat := *c
+		lastActivity := c.CreatedAt
+		for _, m := range r.chatMessages[c.ID] {
+			if m.CreatedAt.After(lastActivity) {
+				lastActivity = m.CreatedAt
+			}
+		}
+		chat.LastActivityAt = &lastActivity
+
+		if query.Cursor != nil && direction*compareTuple(sortValue(&chat), chat.ID, query.Cursor.SortValue, query.Cursor.ID) <= 0 {
+			continue
+		}
+		chats = append(chats, &chat)
+	}
+
+	slices.SortFunc(chats, func(a, b *models.Chat) int {
+		return direction * compareTuple(sortValue(a), a.ID, sortValue(b), b.ID)
+	})
+
+	hasMore := len(chats) > query.Limit
+	if hasMore {
+		chats = chats[:query.Limit]
+	}
+
+	return &models.ChatListResponse{
+		Chats:   chats,
+		HasMore: hasMore,
+	}, nil
+}
+
+func (r *MemoryRepository) UpdateChat(ctx context.Context, chatId int, title string, version int) (*models.Chat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chat, ok := r.chats[chatId]
+	if !ok {
+		return nil, suberrors.ErrChatNotFound
+	}
+	if version > 0 && chat.Version != version {
+		return nil, suberrors.ErrChatVersionMismatch
+	}
+
+	chat.Title = title
+	chat.Version++
+	chat.UpdatedAt = r.now()
+
+	updated := *chat
+	return &updated, nil
+}
+
+func (r *MemoryRepository) DeleteChat(ctx context.Context, chatId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.chats[chatId]; !ok {
+		return suberrors.ErrChatNotFound
+	}
+
+	for _, m := range r.chatMessages[chatId] {
+		delete(r.revisions, m.ID)
+	}
+	delete(r.chatMessages, chatId)
+	delete(r.members, chatId)
+	delete(r.chats, chatId)
+
+	r.publish(&models.Event{Type: models.EventChatDeleted, ChatID: chatId})
+	return nil
+}
+
+func (r *MemoryRepository) CreateMessage(ctx context.Context, chatId int, message *models.Message) (*models.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.chats[chatId]; !ok {
+		return nil, suberrors.ErrChatNotFound
+	}
+
+	r.lastID.message++
+	message.ID = r.lastID.message
+	message.ChatID = chatId
+	message.CreatedAt = r.now()
+	r.chatMessages[chatId] = append(r.chatMessages[chatId], copyMessage(message))
+
+	r.publish(&models.Event{Type: models.EventMessageCreated, ChatID: chatId, Message: copyMessage(message)})
+	return message, nil
+}
+
+// CreateMessages stores messages all at once. Each keeps its CreatedAt when
+// set.
+func (r *MemoryRepository) CreateMessages(ctx context.Context, chatId int, messages []*models.Message) ([]*models.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.chats[chatId]; !ok {
+		return nil, suberrors.ErrChatNotFound
+	}
+
+	now := r.now()
+	for _, message := range messages {
+		r.lastID.message++
+		message.ID = r.lastID.message
+		message.ChatID = chatId
+		if message.CreatedAt.IsZero() {
+			message.CreatedAt = now
+		}
+		r.chatMessages[chatId] = append(r.chatMessages[chatId], copyMessage(message))
+	}
+
+	for _, message := range messages {
+		r.publish(&models.Event{Type: models.EventMessageCreated, ChatID: chatId, Message: copyMessage(message)})
+	}
+	return messages, nil
+}
+
+func (r *MemoryRepository) GetMessage(ctx context.Context, chatId int, messageId int) (*models.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m := r.findMessage(chatId, messageId)
+	if m == nil {
+		return nil, suberrors.ErrMessageNotFound
+	}
+	return copyMessage(m), nil
+}
+
+func (r *MemoryRepository) UpdateMessage(ctx context.Context, chatId int, messageId int, text string) (*models.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := r.findMessage(chatId, messageId)
+	if m == nil || m.DeletedAt != nil {
+		return nil, suberrors.ErrMessageNotFound
+	}
+
+	now := r.now()
+	r.lastID.revision++
+	r.revisions[m.ID] = append(r.revisions[m.ID], &models.MessageRevision{
+		ID:        r.lastID.revision,
+		MessageID: m.ID,
+		Text:      m.Text,
+		CreatedAt: now,
+	})
+
+	m.Text = text
+	m.EditedAt = &now
+	return copyMessage(m), nil
+}
+
+func (r *MemoryRepository) DeleteMessage(ctx context.Context, chatId int, messageId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := r.findMessage(chatId, messageId)
+	if m == nil || m.DeletedAt != nil {
+		return suberrors.ErrMessageNotFound
+	}
+
+	now := r.now()
+	m.DeletedAt = &now
+	return nil
+}
+
+func (r *MemoryRepository) ListMessagesAfter(ctx context.Context, chatId int, afterId int, limit int) ([]*models.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.chats[chatId]; !ok {
+		return nil, suberrors.ErrChatNotFound
+	}
+
+	// Messages of a chat are kept in id order.
+	messages := []*models.Message{}
+	for _, m := range r.chatMessages[chatId] {
+		if len(messages) == limit {
+			break
+		}
+		if m.ID > afterId {
+			messages = append(messages, copyMessage(m))
+		}
+	}
+	return messages, nil
+}
+
+// Search matches whole words, ignoring case: every word of the query must
+// occur in the message, and none of the words prefixed with "-". Rank is the
+// share of the message's words that matched.
+func (r *MemoryRepository) Search(ctx context.Context, query *models.SearchQuery) (*models.SearchResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if query.ChatID > 0 {
+		if _, ok := r.chats[query.ChatID]; !ok {
+			return nil, suberrors.ErrChatNotFound
+		}
+	}
+
+	include, exclude := parseSearchQuery(query.Query)
+	if len(include) == 0 {
+		return &models.SearchResponse{Results: []*models.SearchResult{}}, nil
+	}
+
+	var results []*models.SearchResult
+	for chatId, messages := range r.chatMessages {
+		if query.ChatID > 0 && chatId != query.ChatID {
+			continue
+		}
+		if query.MemberID != "" {
+			if _, ok := r.members[chatId][query.MemberID]; !ok {
+				continue
+			}
+		}
+		for _, m := range messages {
+			if m.DeletedAt != nil {
+				continue
+			}
+			if result := matchMessage(m, include, exclude); result != nil {
+				results = append(results, result)
+			}
+		}
+	}
+
+	slices.SortFunc(results, func(a, b *models.SearchResult) int {
+		if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Message.ID, a.Message.ID)
+	})
+
+	if query.Cursor != nil {
+		results = results[min(query.Cursor.Offset, len(results)):]
+	}
+	hasMore := len(results) > query.Limit
+	if hasMore {
+		results = results[:query.Limit]
+	}
+
+	return &models.SearchResponse{
+		Results: append([]*models.SearchResult{}, results...),
+		HasMore: hasMore,
+	}, nil
+}
+
+// GetChatRole returns the role of a user in a chat, or an empty string when
+// the user is not a member.
+func (r *MemoryRepository) GetChatRole(ctx context.Context, chatId int, userId string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.chats[chatId]; !ok {
+		return "", suberrors.ErrChatNotFound
+	}
+	if member, ok := r.members[chatId][userId]; ok {
+		return member.Role, nil
+	}
+	return "", nil
+}
+
+func (r *MemoryRepository) ListChatMembers(ctx context.Context, chatId int) ([]*models.ChatMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := make([]*models.ChatMember, 0, len(r.members[chatId]))
+	for _, m := range r.members[chatId] {
+		member := *m
+		members = append(members, &member)
+	}
+	slices.SortFunc(members, func(a, b *models.ChatMember) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.UserID, b.UserID)
+	})
+	return members, nil
+}
+
+// AddChatMember adds a user to a chat, or changes the role of an existing member.
+func (r *MemoryRepository) AddChatMember(ctx context.Context, member *models.ChatMember) (*models.ChatMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.chats[member.ChatID]; !ok {
+		return nil, suberrors.ErrChatNotFound
+	}
+
+	if existing, ok := r.members[member.ChatID][member.UserID]; ok {
+		existing.Role = member.Role
+		*member = *existing
+		return member, nil
+	}
+
+	member.CreatedAt = r.now()
+	stored := *member
+	r.members[member.ChatID][member.UserID] = &stored
+	return member, nil
+}
+
+func (r *MemoryRepository) RemoveChatMember(ctx context.Context, chatId int, userId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.members[chatId][userId]; !ok {
+		return suberrors.ErrMemberNotFound
+	}
+	delete(r.members[chatId], userId)
+	return nil
+}
+
+func (r *MemoryRepository) CountChatOwners(ctx context.Context, chatId int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	owners := 0
+	for _, m := range r.members[chatId] {
+		if m.Role == models.ChatRoleOwner {
+			owners++
+		}
+	}
+	return owners, nil
+}
+
+// CreateAPIKey stores a key. Like the api_keys table it rejects a hash that
+// is already stored and a name that an unrevoked key already has.
+func (r *MemoryRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range r.apiKeys {
+		if k.KeyHash == key.KeyHash || (k.Name == key.Name && k.RevokedAt == nil) {
+			return nil, errors.New("api key already exists")
+		}
+	}
+
+	r.lastID.apiKey++
+	key.ID = r.lastID.apiKey
+	key.CreatedAt = r.now()
+	stored := *key
+	r.apiKeys[key.ID] = &stored
+	return key, nil
+}
+
+// GetAPIKey finds an unrevoked key by the hash of its value.
+func (r *MemoryRepository) GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, k := range r.apiKeys {
+		if k.KeyHash == keyHash && k.RevokedAt == nil {
+			key := *k
+			return &key, nil
+		}
+	}
+	return nil, suberrors.ErrAPIKeyNotFound
+}
+
+func (r *MemoryRepository) RevokeAPIKey(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range r.apiKeys {
+		if k.Name == name && k.RevokedAt == nil {
+			now := r.now()
+			k.RevokedAt = &now
+			return nil
+		}
+	}
+	return suberrors.ErrAPIKeyNotFound
+}
+
+// publish must be called with r.mu held, so events go out in commit order.
+func (r *MemoryRepository) publish(event *models.Event) {
+	if r.publisher != nil {
+		r.publisher.Publish(event)
+	}
+}
+
+// findMessage must be called with r.mu held.
+func (r *MemoryRepository) findMessage(chatId int, messageId int) *models.Message {
+	for _, m := range r.chatMessages[chatId] {
+		if m.ID == messageId {
+			return m
+		}
+	}
+	return nil
+}
+
+func copyMessage(m *models.Message) *models.Message {
+	message := *m
+	return &message
+}
+
+// compareMessage orders m against the (created_at, id) position of a cursor.
+func compareMessage(m *models.Message, createdAt time.Time, id int) int {
+	return compareTuple(m.CreatedAt, m.ID, createdAt, id)
+}
+
+func compareTuple(at time.Time, id int, otherAt time.Time, otherId int) int {
+	if c := at.Compare(otherAt); c != 0 {
+		return c
+	}
+	return cmp.Compare(id, otherId)
+}
+
+// matchMessage returns the search result for m, or nil if it does not match.
+func matchMessage(m *models.Message, include, exclude []string) *models.SearchResult {
+	text := stripSnippetMarks(m.Text)
+	spans := wordSpans(text)
+
+	words := make(map[string]bool, len(spans))
+	for _, s := range spans {
+		words[strings.ToLower(text[s[0]:s[1]])] = true
+	}
+	for _, w := range exclude {
+		if words[w] {
+			return nil
+		}
+	}
+	for _, w := range include {
+		if !words[w] {
+			return nil
+		}
+	}
+
+	snippet, hits := markWords(text, include)
+	return &models.SearchResult{
+		Message: copyMessage(m),
+		Rank:    float64(hits) / float64(len(spans)),
+		Snippet: highlightSnippet(snippet),
+	}
+}
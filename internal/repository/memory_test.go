@@ -0,0 +1,40 @@
+package repository_test
+
+import (
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/repository"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	events []*models.Event
+}
+
+func (p *recordingPublisher) Publish(event *models.Event) {
+	p.events = append(p.events, event)
+}
+
+func TestMemoryRepository_PublishesEvents(t *testing.T) {
+	ctx := context.Background()
+	publisher := &recordingPublisher{}
+	repo := repository.NewMemoryRepository(publisher)
+
+	chat, err := repo.CreateChat(ctx, &models.Chat{Title: "General"}, "alice")
+	require.NoError(t, err)
+	message, err := repo.CreateMessage(ctx, chat.ID, &models.Message{SenderID: "alice", Text: "hello"})
+	require.NoError(t, err)
+	require.NoError(t, repo.DeleteChat(ctx, chat.ID))
+
+	require.Equal(t, []*models.Event{
+		{Type: models.EventMessageCreated, ChatID: chat.ID, Message: message},
+		{Type: models.EventChatDeleted, ChatID: chat.ID},
+	}, publisher.events)
+
+	// Writes that fail publish nothing.
+	_, err = repo.CreateMessage(ctx, chat.ID, &models.Message{Text: "too late"})
+	require.Error(t, err)
+	require.Len(t, publisher.events, 2)
+}
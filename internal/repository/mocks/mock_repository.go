@@ -11,6 +11,7 @@ package mocks
 
 import (
 	models "TestHitalent/internal/models"
+	context "context"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -40,61 +41,361 @@ func (m *MockHiTalentRepositoryInterface) EXPECT() *MockHiTalentRepositoryInterf
 	return m.recorder
 }
 
+// AddChatMember mocks base method.
+func (m *MockHiTalentRepositoryInterface) AddChatMember(ctx context.Context, member *models.ChatMember) (*models.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddChatMember", ctx, member)
+	ret0, _ := ret[0].(*models.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddChatMember indicates an expected call of AddChatMember.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) AddChatMember(ctx, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddChatMember", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).AddChatMember), ctx, member)
+}
+
+// ChatExists mocks base method.
+func (m *MockHiTalentRepositoryInterface) ChatExists(ctx context.Context, chatId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChatExists", ctx, chatId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChatExists indicates an expected call of ChatExists.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) ChatExists(ctx, chatId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChatExists", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).ChatExists), ctx, chatId)
+}
+
+// CountChatOwners mocks base method.
+func (m *MockHiTalentRepositoryInterface) CountChatOwners(ctx context.Context, chatId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountChatOwners", ctx, chatId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountChatOwners indicates an expected call of CountChatOwners.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) CountChatOwners(ctx, chatId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountChatOwners", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).CountChatOwners), ctx, chatId)
+}
+
 // CreateChat mocks base method.
-func (m *MockHiTalentRepositoryInterface) CreateChat(chat *models.Chat) (*models.Chat, error) {
+func (m *MockHiTalentRepositoryInterface) CreateChat(ctx context.Context, chat *models.Chat, ownerId string) (*models.Chat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateChat", chat)
+	ret := m.ctrl.Call(m, "CreateChat", ctx, chat, ownerId)
 	ret0, _ := ret[0].(*models.Chat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateChat indicates an expected call of CreateChat.
-func (mr *MockHiTalentRepositoryInterfaceMockRecorder) CreateChat(chat any) *gomock.Call {
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) CreateChat(ctx, chat, ownerId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChat", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).CreateChat), chat)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChat", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).CreateChat), ctx, chat, ownerId)
 }
 
 // CreateMessage mocks base method.
-func (m *MockHiTalentRepositoryInterface) CreateMessage(chatId int, message *models.Message) (*models.Message, error) {
+func (m *MockHiTalentRepositoryInterface) CreateMessage(ctx context.Context, chatId int, message *models.Message) (*models.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateMessage", chatId, message)
+	ret := m.ctrl.Call(m, "CreateMessage", ctx, chatId, message)
 	ret0, _ := ret[0].(*models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateMessage indicates an expected call of CreateMessage.
-func (mr *MockHiTalentRepositoryInterfaceMockRecorder) CreateMessage(chatId, message any) *gomock.Call {
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) CreateMessage(ctx, chatId, message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).CreateMessage), ctx, chatId, message)
+}
+
+// CreateMessages mocks base method.
+func (m *MockHiTalentRepositoryInterface) CreateMessages(ctx context.Context, chatId int, messages []*models.Message) ([]*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMessages", ctx, chatId, messages)
+	ret0, _ := ret[0].([]*models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMessages indicates an expected call of CreateMessages.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) CreateMessages(ctx, chatId, messages any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).CreateMessage), chatId, message)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessages", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).CreateMessages), ctx, chatId, messages)
 }
 
 // DeleteChat mocks base method.
-func (m *MockHiTalentRepositoryInterface) DeleteChat(chatId int) error {
+func (m *MockHiTalentRepositoryInterface) DeleteChat(ctx context.Context, chatId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteChat", chatId)
+	ret := m.ctrl.Call(m, "DeleteChat", ctx, chatId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteChat indicates an expected call of DeleteChat.
-func (mr *MockHiTalentRepositoryInterfaceMockRecorder) DeleteChat(chatId any) *gomock.Call {
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) DeleteChat(ctx, chatId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChat", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).DeleteChat), ctx, chatId)
+}
+
+// DeleteMessage mocks base method.
+func (m *MockHiTalentRepositoryInterface) DeleteMessage(ctx context.Context, chatId, messageId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMessage", ctx, chatId, messageId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMessage indicates an expected call of DeleteMessage.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) DeleteMessage(ctx, chatId, messageId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessage", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).DeleteMessage), ctx, chatId, messageId)
+}
+
+// GetAPIKey mocks base method.
+func (m *MockHiTalentRepositoryInterface) GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKey", ctx, keyHash)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) GetAPIKey(ctx, keyHash any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChat", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).DeleteChat), chatId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).GetAPIKey), ctx, keyHash)
 }
 
 // GetChat mocks base method.
-func (m *MockHiTalentRepositoryInterface) GetChat(chatId, limit int) (*models.ChatAndMessagesResponse, error) {
+func (m *MockHiTalentRepositoryInterface) GetChat(ctx context.Context, chatId int, query *models.MessagesQuery) (*models.ChatAndMessagesResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetChat", chatId, limit)
+	ret := m.ctrl.Call(m, "GetChat", ctx, chatId, query)
 	ret0, _ := ret[0].(*models.ChatAndMessagesResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetChat indicates an expected call of GetChat.
-func (mr *MockHiTalentRepositoryInterfaceMockRecorder) GetChat(chatId, limit any) *gomock.Call {
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) GetChat(ctx, chatId, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChat", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).GetChat), ctx, chatId, query)
+}
+
+// GetChatRole mocks base method.
+func (m *MockHiTalentRepositoryInterface) GetChatRole(ctx context.Context, chatId int, userId string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChatRole", ctx, chatId, userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChatRole indicates an expected call of GetChatRole.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) GetChatRole(ctx, chatId, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatRole", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).GetChatRole), ctx, chatId, userId)
+}
+
+// GetMessage mocks base method.
+func (m *MockHiTalentRepositoryInterface) GetMessage(ctx context.Context, chatId, messageId int) (*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessage", ctx, chatId, messageId)
+	ret0, _ := ret[0].(*models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessage indicates an expected call of GetMessage.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) GetMessage(ctx, chatId, messageId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessage", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).GetMessage), ctx, chatId, messageId)
+}
+
+// ListChatMembers mocks base method.
+func (m *MockHiTalentRepositoryInterface) ListChatMembers(ctx context.Context, chatId int) ([]*models.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChatMembers", ctx, chatId)
+	ret0, _ := ret[0].([]*models.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChatMembers indicates an expected call of ListChatMembers.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) ListChatMembers(ctx, chatId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChatMembers", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).ListChatMembers), ctx, chatId)
+}
+
+// ListChats mocks base method.
+func (m *MockHiTalentRepositoryInterface) ListChats(ctx context.Context, query *models.ChatsQuery) (*models.ChatListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChats", ctx, query)
+	ret0, _ := ret[0].(*models.ChatListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChats indicates an expected call of ListChats.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) ListChats(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChats", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).ListChats), ctx, query)
+}
+
+// ListMessagesAfter mocks base method.
+func (m *MockHiTalentRepositoryInterface) ListMessagesAfter(ctx context.Context, chatId, afterId, limit int) ([]*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMessagesAfter", ctx, chatId, afterId, limit)
+	ret0, _ := ret[0].([]*models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMessagesAfter indicates an expected call of ListMessagesAfter.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) ListMessagesAfter(ctx, chatId, afterId, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMessagesAfter", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).ListMessagesAfter), ctx, chatId, afterId, limit)
+}
+
+// RemoveChatMember mocks base method.
+func (m *MockHiTalentRepositoryInterface) RemoveChatMember(ctx context.Context, chatId int, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveChatMember", ctx, chatId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveChatMember indicates an expected call of RemoveChatMember.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) RemoveChatMember(ctx, chatId, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveChatMember", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).RemoveChatMember), ctx, chatId, userId)
+}
+
+// Search mocks base method.
+func (m *MockHiTalentRepositoryInterface) Search(ctx context.Context, query *models.SearchQuery) (*models.SearchResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query)
+	ret0, _ := ret[0].(*models.SearchResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) Search(ctx, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).Search), ctx, query)
+}
+
+// UpdateChat mocks base method.
+func (m *MockHiTalentRepositoryInterface) UpdateChat(ctx context.Context, chatId int, title string, version int) (*models.Chat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateChat", ctx, chatId, title, version)
+	ret0, _ := ret[0].(*models.Chat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateChat indicates an expected call of UpdateChat.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) UpdateChat(ctx, chatId, title, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateChat", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).UpdateChat), ctx, chatId, title, version)
+}
+
+// UpdateMessage mocks base method.
+func (m *MockHiTalentRepositoryInterface) UpdateMessage(ctx context.Context, chatId, messageId int, text string) (*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMessage", ctx, chatId, messageId, text)
+	ret0, _ := ret[0].(*models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateMessage indicates an expected call of UpdateMessage.
+func (mr *MockHiTalentRepositoryInterfaceMockRecorder) UpdateMessage(ctx, chatId, messageId, text any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessage", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).UpdateMessage), ctx, chatId, messageId, text)
+}
+
+// MockEventBroker is a mock of EventBroker interface.
+type MockEventBroker struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventBrokerMockRecorder
+	isgomock struct{}
+}
+
+// MockEventBrokerMockRecorder is the mock recorder for MockEventBroker.
+type MockEventBrokerMockRecorder struct {
+	mock *MockEventBroker
+}
+
+// NewMockEventBroker creates a new mock instance.
+func NewMockEventBroker(ctrl *gomock.Controller) *MockEventBroker {
+	mock := &MockEventBroker{ctrl: ctrl}
+	mock.recorder = &MockEventBrokerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventBroker) EXPECT() *MockEventBrokerMockRecorder {
+	return m.recorder
+}
+
+// Subscribe mocks base method.
+func (m *MockEventBroker) Subscribe(chatId int) (<-chan *models.Event, func()) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", chatId)
+	ret0, _ := ret[0].(<-chan *models.Event)
+	ret1, _ := ret[1].(func())
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockEventBrokerMockRecorder) Subscribe(chatId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockEventBroker)(nil).Subscribe), chatId)
+}
+
+// MockTokenVerifier is a mock of TokenVerifier interface.
+type MockTokenVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenVerifierMockRecorder
+	isgomock struct{}
+}
+
+// MockTokenVerifierMockRecorder is the mock recorder for MockTokenVerifier.
+type MockTokenVerifierMockRecorder struct {
+	mock *MockTokenVerifier
+}
+
+// NewMockTokenVerifier creates a new mock instance.
+func NewMockTokenVerifier(ctrl *gomock.Controller) *MockTokenVerifier {
+	mock := &MockTokenVerifier{ctrl: ctrl}
+	mock.recorder = &MockTokenVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenVerifier) EXPECT() *MockTokenVerifierMockRecorder {
+	return m.recorder
+}
+
+// Verify mocks base method.
+func (m *MockTokenVerifier) Verify(token string) (*models.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", token)
+	ret0, _ := ret[0].(*models.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockTokenVerifierMockRecorder) Verify(token any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChat", reflect.TypeOf((*MockHiTalentRepositoryInterface)(nil).GetChat), chatId, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockTokenVerifier)(nil).Verify), token)
 }
@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"TestHitalent/internal/models"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"gorm.io/gorm"
+)
+
+// Names of the statements PreparePgxStatements prepares.
+const (
+	stmtChatExists        = "chat_exists"
+	stmtGetChat           = "get_chat"
+	stmtGetChatBefore     = "get_chat_before"
+	stmtGetChatAfter      = "get_chat_after"
+	stmtGetChatRole       = "get_chat_role"
+	stmtGetAPIKey         = "get_api_key"
+	stmtGetMessage        = "get_message"
+	stmtListMessagesAfter = "list_messages_after"
+	stmtCreateMessage     = "create_message"
+)
+
+// messageColumns match scanMessage. Senders are null for messages written
+// before they were recorded.
+const messageColumns = "id, chat_id, COALESCE(sender_id, '') AS sender_id, COALESCE(sender_name, '') AS sender_name, text, created_at, edited_at, deleted_at"
+
+// withChatMessages selects a chat together with up to $3 of its messages
+// that match where, so a missing chat and a chat without messages are told
+// apart in one round trip: the chat comes with a row of nulls.
+func withChatMessages(where string, direction string) string {
+	return `SELECT c.id, c.title, c.created_at, c.updated_at, c.version,
+       m.id, m.chat_id, m.sender_id, m.sender_name, m.text, m.created_at, m.edited_at, m.deleted_at
+FROM chats c
+LEFT JOIN LATERAL (
+    SELECT ` + messageColumns + `
+    FROM messages
+    WHERE chat_id = c.id AND ` + where + `
+    ORDER BY created_at ` + direction + `, id ` + direction + `
+    LIMIT $3
+) m ON true
+WHERE c.id = $1
+ORDER BY m.created_at ` + direction + `, m.id ` + direction
+}
+
+// getChatSenderFilter matches every sender when $2 is empty.
+const getChatSenderFilter = "($2::varchar = '' OR sender_id = $2::varchar)"
+
+var pgxStatements = map[string]string{
+	stmtChatExists:    `SELECT EXISTS (SELECT 1 FROM chats WHERE id = $1)`,
+	stmtGetChat:       withChatMessages(getChatSenderFilter, "DESC"),
+	stmtGetChatBefore: withChatMessages(getChatSenderFilter+" AND (created_at, id) < ($4::timestamp, $5::int)", "DESC"),
+	stmtGetChatAfter:  withChatMessages(getChatSenderFilter+" AND (created_at, id) > ($4::timestamp, $5::int)", "ASC"),
+	stmtGetChatRole: `SELECT m.role
+FROM chats c
+LEFT JOIN chat_members m ON m.chat_id = c.id AND m.user_id = $2
+WHERE c.id = $1`,
+	stmtGetAPIKey: `SELECT id, name, key_hash, created_at, revoked_at
+FROM api_keys
+WHERE key_hash = $1 AND revoked_at IS NULL
+LIMIT 1`,
+	stmtGetMessage: `SELECT ` + messageColumns + `
+FROM messages
+WHERE chat_id = $1 AND id = $2`,
+	stmtListMessagesAfter: `SELECT m.id, m.chat_id, m.sender_id, m.sender_name, m.text, m.created_at, m.edited_at, m.deleted_at
+FROM chats c
+LEFT JOIN LATERAL (
+    SELECT ` + messageColumns + `
+    FROM messages
+    WHERE chat_id = c.id AND id > $2
+    ORDER BY id ASC
+    LIMIT $3
+) m ON true
+WHERE c.id = $1
+ORDER BY m.id ASC`,
+	// The notification is sent on commit of the statement, like notify does
+	// for GORM transactions.
+	stmtCreateMessage: `WITH inserted AS (
+    INSERT INTO messages (chat_id, sender_id, sender_name, text, created_at)
+    VALUES ($1, $2, $3, $4, $5)
+    RETURNING id, created_at
+)
+SELECT id, created_at,
+       pg_notify($6, json_build_object('type', $7::text, 'chat_id', $1::int, 'message_id', id)::text)
+FROM inserted`,
+}
+
+// PreparePgxStatements prepares the statements of PgxRepository on conn.
+// Pools PgxRepository uses must run it on every new connection.
+func PreparePgxStatements(ctx context.Context, conn *pgx.Conn) error {
+	for name, sql := range pgxStatements {
+		if _, err := conn.Prepare(ctx, name, sql); err != nil {
+			return fmt.Errorf("unable to prepare %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// PgxRepository serves the hot paths, reading chats and posting messages
+// and the lookups every request makes to authorize, with pgx and prepared
+// statements. Everything else goes through HiTalentRepository.
+type PgxRepository struct {
+	*HiTalentRepository
+	pool *pgxpool.Pool
+}
+
+func NewPgxRepository(db *gorm.DB, pool *pgxpool.Pool) *PgxRepository {
+	return &PgxRepository{
+		HiTalentRepository: NewHiTalentRepository(db),
+		pool:               pool,
+	}
+}
+
+// ChatExists reports ErrChatNotFound for a missing chat without loading it.
+func (r *PgxRepository) ChatExists(ctx context.Context, chatId int) error {
+	var exists bool
+	if err := r.pool.QueryRow(ctx, stmtChatExists, chatId).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return suberrors.ErrChatNotFound
+	}
+	return nil
+}
+
+// GetChat loads the chat and a page of its messages in one query.
+func (r *PgxRepository) GetChat(ctx context.Context, chatId int, query *models.MessagesQuery) (*models.ChatAndMessagesResponse, error) {
+	// One extra row tells us whether another page exists.
+	args := []any{chatId, query.SenderID, query.Limit + 1}
+	stmt := stmtGetChat
+	switch {
+	case query.Before != nil:
+		stmt = stmtGetChatBefore
+		args = append(args, query.Before.CreatedAt, query.Before.ID)
+	case query.After != nil:
+		stmt = stmtGetChatAfter
+		args = append(args, query.After.CreatedAt, query.After.ID)
+	}
+
+	rows, err := r.pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chat *models.Chat
+	messages := []*models.Message{}
+	for rows.Next() {
+		var c models.Chat
+		var m nullableMessage
+		if err := rows.Scan(&c.ID, &c.Title, &c.CreatedAt, &c.UpdatedAt, &c.Version,
+			&m.ID, &m.ChatID, &m.SenderID, &m.SenderName, &m.Text, &m.CreatedAt, &m.EditedAt, &m.DeletedAt); err != nil {
+			return nil, err
+		}
+		chat = &c
+		if message := m.message(); message != nil {
+			messages = append(messages, message)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if chat == nil {
+		return nil, suberrors.ErrChatNotFound
+	}
+
+	hasMore := len(messages) > query.Limit
+	if hasMore {
+		messages = messages[:query.Limit]
+	}
+
+	if query.After != nil {
+		slices.Reverse(messages)
+	}
+
+	return &models.ChatAndMessagesResponse{
+		Chat:     chat,
+		Messages: messages,
+		HasMore:  hasMore,
+	}, nil
+}
+
+func (r *PgxRepository) CreateMessage(ctx context.Context, chatId int, message *models.Message) (*models.Message, error) {
+	message.ChatID = chatId
+
+	err := r.pool.QueryRow(ctx, stmtCreateMessage,
+		chatId, message.SenderID, message.SenderName, message.Text, time.Now().UTC(),
+		models.EventsChannel, models.EventMessageCreated,
+	).Scan(&message.ID, &message.CreatedAt, nil)
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, suberrors.ErrChatNotFound
+		}
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func (r *PgxRepository) GetMessage(ctx context.Context, chatId int, messageId int) (*models.Message, error) {
+	message, err := scanMessage(r.pool.QueryRow(ctx, stmtGetMessage, chatId, messageId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, suberrors.ErrMessageNotFound
+		}
+		return nil, err
+	}
+	return message, nil
+}
+
+func (r *PgxRepository) ListMessagesAfter(ctx context.Context, chatId int, afterId int, limit int) ([]*models.Message, error) {
+	rows, err := r.pool.Query(ctx, stmtListMessagesAfter, chatId, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := false
+	messages := []*models.Message{}
+	for rows.Next() {
+		var m nullableMessage
+		if err := rows.Scan(&m.ID, &m.ChatID, &m.SenderID, &m.SenderName, &m.Text, &m.CreatedAt, &m.EditedAt, &m.DeletedAt); err != nil {
+			return nil, err
+		}
+		found = true
+		if message := m.message(); message != nil {
+			messages = append(messages, message)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, suberrors.ErrChatNotFound
+	}
+	return messages, nil
+}
+
+// GetChatRole returns the role of a user in a chat, or an empty string when
+// the user is not a member.
+func (r *PgxRepository) GetChatRole(ctx context.Context, chatId int, userId string) (string, error) {
+	var role *string
+	if err := r.pool.QueryRow(ctx, stmtGetChatRole, chatId, userId).Scan(&role); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", suberrors.ErrChatNotFound
+		}
+		return "", err
+	}
+
+	if role == nil {
+		return "", nil
+	}
+	return *role, nil
+}
+
+// GetAPIKey finds an unrevoked key by the hash of its value.
+func (r *PgxRepository) GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.pool.QueryRow(ctx, stmtGetAPIKey, keyHash).
+		Scan(&key.ID, &key.Name, &key.KeyHash, &key.CreatedAt, &key.RevokedAt); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, suberrors.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// nullableMessage scans the message side of a LEFT JOIN, which is all nulls
+// when nothing matched.
+type nullableMessage struct {
+	ID         *int
+	ChatID     *int
+	SenderID   *string
+	SenderName *string
+	Text       *string
+	CreatedAt  *time.Time
+	EditedAt   *time.Time
+	DeletedAt  *time.Time
+}
+
+func (m *nullableMessage) message() *models.Message {
+	if m.ID == nil {
+		return nil
+	}
+	return &models.Message{
+		ID:         *m.ID,
+		ChatID:     *m.ChatID,
+		SenderID:   *m.SenderID,
+		SenderName: *m.SenderName,
+		Text:       *m.Text,
+		CreatedAt:  *m.CreatedAt,
+		EditedAt:   m.EditedAt,
+		DeletedAt:  m.DeletedAt,
+	}
+}
+
+func scanMessage(row pgx.Row) (*models.Message, error) {
+	var m models.Message
+	if err := row.Scan(&m.ID, &m.ChatID, &m.SenderID, &m.SenderName, &m.Text, &m.CreatedAt, &m.EditedAt, &m.DeletedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -4,36 +4,105 @@ import (
 	"TestHitalent/internal/models"
 	"TestHitalent/pkg/suberrors"
 	"context"
+	"encoding/json"
 	"errors"
+	"html"
+	"slices"
+	"strings"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+const (
+	// searchConfig must match the text search configuration the
+	// messages.search_vector column is generated with.
+	searchConfig = "simple"
+
+	// ts_headline returns the raw message text, so matches are delimited with
+	// private-use characters, which are stripped from the text beforehand,
+	// and turned into <mark> tags only after the text has been HTML-escaped.
+	snippetStart    = "\uE000"
+	snippetStop     = "\uE001"
+	headlineOptions = "StartSel=" + snippetStart + ", StopSel=" + snippetStop + ", MaxFragments=2, MaxWords=20, MinWords=5"
+)
+
+type searchRow struct {
+	models.Message
+	Rank    float64
+	Snippet string
+}
+
 type HiTalentRepository struct {
-	db  *gorm.DB
-	ctx context.Context
+	db *gorm.DB
 }
 
-func NewHiTalentRepository(db *gorm.DB, ctx context.Context) *HiTalentRepository {
+func NewHiTalentRepository(db *gorm.DB) *HiTalentRepository {
 	return &HiTalentRepository{
-		db:  db,
-		ctx: ctx,
+		db: db,
 	}
 }
 
-func (r *HiTalentRepository) CreateChat(chat *models.Chat) (*models.Chat, error) {
-	if err := r.db.WithContext(r.ctx).Create(chat).Error; err != nil {
+// CreateChat stores a chat together with its owner membership and initial
+// messages. Nobody can be subscribed to the chat yet, so the messages are
+// not announced.
+func (r *HiTalentRepository) CreateChat(ctx context.Context, chat *models.Chat, ownerId string) (*models.Chat, error) {
+	err := r.db.
+		WithContext(ctx).
+		Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(chat).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Create(&models.ChatMember{
+				ChatID: chat.ID,
+				UserID: ownerId,
+				Role:   models.ChatRoleOwner,
+			}).Error; err != nil {
+				return err
+			}
+
+			if len(chat.Messages) == 0 {
+				return nil
+			}
+			for _, message := range chat.Messages {
+				message.ChatID = chat.ID
+			}
+			return tx.Create(chat.Messages).Error
+		})
+	if err != nil {
 		return nil, err
 	}
 	return chat, nil
 }
 
-func (r *HiTalentRepository) GetChat(chatId int, limit int) (*models.ChatAndMessagesResponse, error) {
+// ChatExists reports ErrChatNotFound for a missing chat without loading it.
+func (r *HiTalentRepository) ChatExists(ctx context.Context, chatId int) error {
+	var ids []int
+
+	if err := r.db.
+		WithContext(ctx).
+		Model(&models.Chat{}).
+		Where("id = ?", chatId).
+		Limit(1).
+		Pluck("id", &ids).Error; err != nil {
+
+		return err
+	}
+
+	if len(ids) == 0 {
+		return suberrors.ErrChatNotFound
+	}
+
+	return nil
+}
+
+func (r *HiTalentRepository) GetChat(ctx context.Context, chatId int, query *models.MessagesQuery) (*models.ChatAndMessagesResponse, error) {
 	var chat models.Chat
 
 	if err := r.db.
-		WithContext(r.ctx).
+		WithContext(ctx).
 		First(&chat, chatId).Error; err != nil {
 
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -42,46 +111,476 @@ func (r *HiTalentRepository) GetChat(chatId int, limit int) (*models.ChatAndMess
 		return nil, err
 	}
 
+	tx := r.db.
+		WithContext(ctx).
+		Where("chat_id = ?", chatId)
+
+	if query.SenderID != "" {
+		tx = tx.Where("sender_id = ?", query.SenderID)
+	}
+
+	switch {
+	case query.Before != nil:
+		tx = tx.
+			Where("(created_at, id) < (?, ?)", query.Before.CreatedAt, query.Before.ID).
+			Order("created_at DESC, id DESC")
+	case query.After != nil:
+		tx = tx.
+			Where("(created_at, id) > (?, ?)", query.After.CreatedAt, query.After.ID).
+			Order("created_at ASC, id ASC")
+	default:
+		tx = tx.Order("created_at DESC, id DESC")
+	}
+
 	var messages []*models.Message
 
-	if err := r.db.
-		WithContext(r.ctx).
-		Where("chat_id = ?", chatId).
-		Order("created_at DESC").
-		Limit(limit).
+	// One extra row tells us whether another page exists.
+	if err := tx.
+		Limit(query.Limit + 1).
 		Find(&messages).Error; err != nil {
 
 		return nil, err
 	}
 
+	hasMore := len(messages) > query.Limit
+	if hasMore {
+		messages = messages[:query.Limit]
+	}
+
+	if query.After != nil {
+		slices.Reverse(messages)
+	}
+
 	return &models.ChatAndMessagesResponse{
 		Chat:     &chat,
 		Messages: messages,
+		HasMore:  hasMore,
 	}, nil
 }
 
-func (r *HiTalentRepository) DeleteChat(chatId int) error {
+func (r *HiTalentRepository) ListChats(ctx context.Context, query *models.ChatsQuery) (*models.ChatListResponse, error) {
+	lastActivity := r.db.
+		Model(&models.Message{}).
+		Select("MAX(messages.created_at)").
+		Where("messages.chat_id = chats.id")
+
+	withActivity := r.db.
+		Model(&models.Chat{}).
+		Select("chats.*, COALESCE((?), chats.created_at) AS last_activity_at", lastActivity)
+
+	tx := r.db.
+		WithContext(ctx).
+		Table("(?) AS chats", withActivity)
+
+	if query.MemberID != "" {
+		tx = tx.Where("id IN (?)", memberChats(r.db, query.MemberID))
+	}
+	if query.Title != "" {
+		tx = tx.Where("title ILIKE ?", "%"+escapeLike(query.Title)+"%")
+	}
+	if query.CreatedFrom != nil {
+		tx = tx.Where("created_at >= ?", *query.CreatedFrom)
+	}
+	if query.CreatedTo != nil {
+		tx = tx.Where("created_at <= ?", *query.CreatedTo)
+	}
+
+	sortColumn := "created_at"
+	if query.Sort == models.ChatSortLastActivity {
+		sortColumn = "last_activity_at"
+	}
+
+	cmp, direction := "<", "DESC"
+	if query.Order == models.SortOrderAsc {
+		cmp, direction = ">", "ASC"
+	}
+
+	if query.Cursor != nil {
+		tx = tx.Where("("+sortColumn+", id) "+cmp+" (?, ?)", query.Cursor.SortValue, query.Cursor.ID)
+	}
+
+	var chats []*models.Chat
+
+	if err := tx.
+		Order(sortColumn + " " + direction + ", id " + direction).
+		Limit(query.Limit + 1).
+		Find(&chats).Error; err != nil {
+
+		return nil, err
+	}
+
+	hasMore := len(chats) > query.Limit
+	if hasMore {
+		chats = chats[:query.Limit]
+	}
+
+	return &models.ChatListResponse{
+		Chats:   chats,
+		HasMore: hasMore,
+	}, nil
+}
+
+func (r *HiTalentRepository) UpdateChat(ctx context.Context, chatId int, title string, version int) (*models.Chat, error) {
+	var chat models.Chat
+
+	tx := r.db.
+		WithContext(ctx).
+		Model(&chat).
+		Clauses(clause.Returning{}).
+		Where("id = ?", chatId)
+
+	if version > 0 {
+		tx = tx.Where("version = ?", version)
+	}
+
+	result := tx.Updates(map[string]any{
+		"title":      title,
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": r.db.NowFunc(),
+	})
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := r.db.
+			WithContext(ctx).
+			Model(&models.Chat{}).
+			Where("id = ?", chatId).
+			Count(&count).Error; err != nil {
+
+			return nil, err
+		}
+		if count == 0 {
+			return nil, suberrors.ErrChatNotFound
+		}
+		return nil, suberrors.ErrChatVersionMismatch
+	}
+
+	return &chat, nil
+}
+
+func (r *HiTalentRepository) DeleteChat(ctx context.Context, chatId int) error {
+	return r.db.
+		WithContext(ctx).
+		Transaction(func(tx *gorm.DB) error {
+			result := tx.Delete(&models.Chat{}, chatId)
+
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				return suberrors.ErrChatNotFound
+			}
+
+			return notify(tx, &models.EventNotification{
+				Type:   models.EventChatDeleted,
+				ChatID: chatId,
+			})
+		})
+}
+
+func (r *HiTalentRepository) CreateMessage(ctx context.Context, chatId int, message *models.Message) (*models.Message, error) {
+	message.ChatID = chatId
+
+	err := r.db.
+		WithContext(ctx).
+		Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(message).Error; err != nil {
+				return err
+			}
+
+			return notify(tx, &models.EventNotification{
+				Type:      models.EventMessageCreated,
+				ChatID:    chatId,
+				MessageID: message.ID,
+			})
+		})
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, suberrors.ErrChatNotFound
+		}
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// CreateMessages stores messages in one multi-row insert, all or none. Each
+// keeps its CreatedAt when set.
+func (r *HiTalentRepository) CreateMessages(ctx context.Context, chatId int, messages []*models.Message) ([]*models.Message, error) {
+	ids := make([]int, 0, len(messages))
+
+	err := r.db.
+		WithContext(ctx).
+		Transaction(func(tx *gorm.DB) error {
+			for _, message := range messages {
+				message.ChatID = chatId
+			}
+			if err := tx.Create(messages).Error; err != nil {
+				return err
+			}
+
+			for _, message := range messages {
+				ids = append(ids, message.ID)
+			}
+			// One statement announces every message, in the order they
+			// were stored.
+			return tx.Exec(`SELECT pg_notify(?, json_build_object('type', ?::text, 'chat_id', chat_id, 'message_id', id)::text)
+FROM (SELECT chat_id, id FROM messages WHERE id IN ? ORDER BY id) AS created`,
+				models.EventsChannel, models.EventMessageCreated, ids).Error
+		})
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, suberrors.ErrChatNotFound
+		}
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *HiTalentRepository) GetMessage(ctx context.Context, chatId int, messageId int) (*models.Message, error) {
+	var message models.Message
+
+	if err := r.db.
+		WithContext(ctx).
+		Where("chat_id = ?", chatId).
+		First(&message, messageId).Error; err != nil {
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, suberrors.ErrMessageNotFound
+		}
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func (r *HiTalentRepository) UpdateMessage(ctx context.Context, chatId int, messageId int, text string) (*models.Message, error) {
+	var message models.Message
+
+	err := r.db.
+		WithContext(ctx).
+		Transaction(func(tx *gorm.DB) error {
+			if err := tx.
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("chat_id = ? AND deleted_at IS NULL", chatId).
+				First(&message, messageId).Error; err != nil {
+
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return suberrors.ErrMessageNotFound
+				}
+				return err
+			}
+
+			revision := &models.MessageRevision{
+				MessageID: message.ID,
+				Text:      message.Text,
+			}
+			if err := tx.Create(revision).Error; err != nil {
+				return err
+			}
+
+			editedAt := tx.NowFunc()
+			message.Text = text
+			message.EditedAt = &editedAt
+
+			return tx.
+				Model(&message).
+				Updates(map[string]any{"text": text, "edited_at": editedAt}).Error
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func (r *HiTalentRepository) DeleteMessage(ctx context.Context, chatId int, messageId int) error {
 	result := r.db.
-		WithContext(r.ctx).
-		Delete(&models.Chat{}, chatId)
+		WithContext(ctx).
+		Model(&models.Message{}).
+		Where("id = ? AND chat_id = ? AND deleted_at IS NULL", messageId, chatId).
+		Update("deleted_at", r.db.NowFunc())
 
 	if result.Error != nil {
 		return result.Error
 	}
 
 	if result.RowsAffected == 0 {
-		return suberrors.ErrChatNotFound
+		return suberrors.ErrMessageNotFound
 	}
 
 	return nil
 }
 
-func (r *HiTalentRepository) CreateMessage(chatId int, message *models.Message) (*models.Message, error) {
-	message.ChatID = chatId
+func (r *HiTalentRepository) ListMessagesAfter(ctx context.Context, chatId int, afterId int, limit int) ([]*models.Message, error) {
+	var chat models.Chat
+
+	if err := r.db.
+		WithContext(ctx).
+		Select("id").
+		First(&chat, chatId).Error; err != nil {
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, suberrors.ErrChatNotFound
+		}
+		return nil, err
+	}
+
+	var messages []*models.Message
+
+	if err := r.db.
+		WithContext(ctx).
+		Where("chat_id = ? AND id > ?", chatId, afterId).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error; err != nil {
+
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *HiTalentRepository) Search(ctx context.Context, query *models.SearchQuery) (*models.SearchResponse, error) {
+	if query.ChatID > 0 {
+		var chat models.Chat
+
+		if err := r.db.
+			WithContext(ctx).
+			Select("id").
+			First(&chat, query.ChatID).Error; err != nil {
+
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, suberrors.ErrChatNotFound
+			}
+			return nil, err
+		}
+	}
+
+	tx := r.db.
+		WithContext(ctx).
+		Table("messages, websearch_to_tsquery(?, ?) AS query", searchConfig, query.Query).
+		Select("messages.*, ts_rank(messages.search_vector, query) AS rank, ts_headline(?, translate(messages.text, ?, ''), query, ?) AS snippet",
+			searchConfig, snippetStart+snippetStop, headlineOptions).
+		Where("messages.search_vector @@ query AND messages.deleted_at IS NULL")
+
+	if query.ChatID > 0 {
+		tx = tx.Where("messages.chat_id = ?", query.ChatID)
+	}
+	if query.MemberID != "" {
+		tx = tx.Where("messages.chat_id IN (?)", memberChats(r.db, query.MemberID))
+	}
+	if query.Cursor != nil {
+		tx = tx.Offset(query.Cursor.Offset)
+	}
+
+	var rows []*searchRow
+
+	// One extra row tells us whether another page exists.
+	if err := tx.
+		Order("rank DESC, messages.id DESC").
+		Limit(query.Limit + 1).
+		Scan(&rows).Error; err != nil {
+
+		return nil, err
+	}
+
+	hasMore := len(rows) > query.Limit
+	if hasMore {
+		rows = rows[:query.Limit]
+	}
+
+	results := make([]*models.SearchResult, 0, len(rows))
+	for _, row := range rows {
+		message := row.Message
+		results = append(results, &models.SearchResult{
+			Message: &message,
+			Rank:    row.Rank,
+			Snippet: highlightSnippet(row.Snippet),
+		})
+	}
+
+	return &models.SearchResponse{
+		Results: results,
+		HasMore: hasMore,
+	}, nil
+}
+
+// highlightSnippet HTML-escapes a ts_headline result and wraps the matches it
+// delimited in <mark> tags.
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>").Replace(escaped)
+}
+
+// GetChatRole returns the role of a user in a chat, or an empty string when
+// the user is not a member.
+func (r *HiTalentRepository) GetChatRole(ctx context.Context, chatId int, userId string) (string, error) {
+	var row struct {
+		Role *string
+	}
+
+	result := r.db.
+		WithContext(ctx).
+		Table("chats").
+		Select("chat_members.role").
+		Joins("LEFT JOIN chat_members ON chat_members.chat_id = chats.id AND chat_members.user_id = ?", userId).
+		Where("chats.id = ?", chatId).
+		Limit(1).
+		Scan(&row)
+
+	if result.Error != nil {
+		return "", result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return "", suberrors.ErrChatNotFound
+	}
+
+	if row.Role == nil {
+		return "", nil
+	}
+
+	return *row.Role, nil
+}
+
+func (r *HiTalentRepository) ListChatMembers(ctx context.Context, chatId int) ([]*models.ChatMember, error) {
+	var members []*models.ChatMember
+
+	if err := r.db.
+		WithContext(ctx).
+		Where("chat_id = ?", chatId).
+		Order("created_at ASC, user_id ASC").
+		Find(&members).Error; err != nil {
+
+		return nil, err
+	}
 
+	return members, nil
+}
+
+// AddChatMember adds a user to a chat, or changes the role of an existing member.
+func (r *HiTalentRepository) AddChatMember(ctx context.Context, member *models.ChatMember) (*models.ChatMember, error) {
 	err := r.db.
-		WithContext(r.ctx).
-		Create(message).Error
+		WithContext(ctx).
+		Clauses(
+			clause.OnConflict{
+				Columns:   []clause.Column{{Name: "chat_id"}, {Name: "user_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"role"}),
+			},
+			clause.Returning{},
+		).
+		Create(member).Error
 
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -91,5 +590,102 @@ func (r *HiTalentRepository) CreateMessage(chatId int, message *models.Message)
 		return nil, err
 	}
 
-	return message, nil
+	return member, nil
+}
+
+func (r *HiTalentRepository) RemoveChatMember(ctx context.Context, chatId int, userId string) error {
+	result := r.db.
+		WithContext(ctx).
+		Where("chat_id = ? AND user_id = ?", chatId, userId).
+		Delete(&models.ChatMember{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return suberrors.ErrMemberNotFound
+	}
+
+	return nil
+}
+
+func (r *HiTalentRepository) CountChatOwners(ctx context.Context, chatId int) (int, error) {
+	var owners int64
+
+	if err := r.db.
+		WithContext(ctx).
+		Model(&models.ChatMember{}).
+		Where("chat_id = ? AND role = ?", chatId, models.ChatRoleOwner).
+		Count(&owners).Error; err != nil {
+
+		return 0, err
+	}
+
+	return int(owners), nil
+}
+
+func (r *HiTalentRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// GetAPIKey finds an unrevoked key by the hash of its value.
+func (r *HiTalentRepository) GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+
+	if err := r.db.
+		WithContext(ctx).
+		Where("key_hash = ? AND revoked_at IS NULL", keyHash).
+		First(&key).Error; err != nil {
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, suberrors.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *HiTalentRepository) RevokeAPIKey(ctx context.Context, name string) error {
+	result := r.db.
+		WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("name = ? AND revoked_at IS NULL", name).
+		Update("revoked_at", r.db.NowFunc())
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return suberrors.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// notify queues a chat event on the transaction. Postgres delivers it to
+// listeners only once the transaction commits, so a rolled back write is
+// never announced.
+func notify(tx *gorm.DB, notification *models.EventNotification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	return tx.Exec("SELECT pg_notify(?, ?)", models.EventsChannel, string(payload)).Error
+}
+
+func memberChats(db *gorm.DB, userId string) *gorm.DB {
+	return db.
+		Model(&models.ChatMember{}).
+		Select("chat_id").
+		Where("user_id = ?", userId)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHighlightSnippet(t *testing.T) {
+	cases := []struct {
+		name     string
+		snippet  string
+		expected string
+	}{
+		{
+			name:     "plain text",
+			snippet:  snippetStart + "Deploy" + snippetStop + " at noon",
+			expected: "<mark>Deploy</mark> at noon",
+		},
+		{
+			name:     "html in text is escaped",
+			snippet:  `<img src=x onerror="alert(1)"> ` + snippetStart + "deploy" + snippetStop + " & <b>ship</b>",
+			expected: `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>deploy</mark> &amp; &lt;b&gt;ship&lt;/b&gt;`,
+		},
+		{
+			name:     "literal mark tags are not trusted",
+			snippet:  "<mark>fake</mark> " + snippetStart + "real" + snippetStop,
+			expected: "&lt;mark&gt;fake&lt;/mark&gt; <mark>real</mark>",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, highlightSnippet(tc.snippet))
+		})
+	}
+}
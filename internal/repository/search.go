@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// The repositories without Postgres full-text search match whole words,
+// ignoring case, and highlight the matches themselves.
+
+// parseSearchQuery splits a query into the words that must and must not
+// occur. Quotes and "or" are ignored.
+func parseSearchQuery(q string) (include, exclude []string) {
+	for _, field := range strings.Fields(q) {
+		negated := strings.HasPrefix(field, "-")
+		for _, w := range wordSpans(field) {
+			word := strings.ToLower(field[w[0]:w[1]])
+			if word == "or" {
+				continue
+			}
+			if negated {
+				exclude = append(exclude, word)
+			} else {
+				include = append(include, word)
+			}
+		}
+	}
+	return include, exclude
+}
+
+// markWords delimits the words of text that are in words with snippetStart
+// and snippetStop, and counts them.
+func markWords(text string, words []string) (string, int) {
+	var marked strings.Builder
+	last, hits := 0, 0
+	for _, s := range wordSpans(text) {
+		if !slices.Contains(words, strings.ToLower(text[s[0]:s[1]])) {
+			continue
+		}
+		hits++
+		marked.WriteString(text[last:s[0]])
+		marked.WriteString(snippetStart + text[s[0]:s[1]] + snippetStop)
+		last = s[1]
+	}
+	marked.WriteString(text[last:])
+	return marked.String(), hits
+}
+
+// stripSnippetMarks removes the characters that delimit matches, so message
+// text cannot forge them.
+func stripSnippetMarks(text string) string {
+	return strings.NewReplacer(snippetStart, "", snippetStop, "").Replace(text)
+}
+
+// wordSpans returns the byte offsets of the runs of letters and digits in s.
+func wordSpans(s string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, c := range s {
+		isWord := unicode.IsLetter(c) || unicode.IsDigit(c)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(s)})
+	}
+	return spans
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"TestHitalent/internal/models"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/glebarez/go-sqlite"
+	"gorm.io/gorm"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteTimeFormat is how pkg/sqlite stores timestamps.
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// SQLiteRepository keeps chats in SQLite, for installs that run a single
+// instance without Postgres. Queries both dialects accept are shared with
+// HiTalentRepository. Search uses an FTS5 index and matches whole words, and
+// events are published to this process only.
+type SQLiteRepository struct {
+	*HiTalentRepository
+	publisher EventPublisher
+}
+
+func NewSQLiteRepository(db *gorm.DB, publisher EventPublisher) *SQLiteRepository {
+	return &SQLiteRepository{
+		HiTalentRepository: NewHiTalentRepository(db),
+		publisher:          publisher,
+	}
+}
+
+// sqliteChatRow is a chat with its last activity, which SQLite returns as
+// text since it is computed.
+type sqliteChatRow struct {
+	models.Chat
+	LastActivity string
+}
+
+func (r *SQLiteRepository) ListChats(ctx context.Context, query *models.ChatsQuery) (*models.ChatListResponse, error) {
+	lastActivity := r.db.
+		Model(&models.Message{}).
+		Select("MAX(messages.created_at)").
+		Where("messages.chat_id = chats.id")
+
+	withActivity := r.db.
+		Model(&models.Chat{}).
+		Select("chats.*, COALESCE((?), chats.created_at) AS last_activity", lastActivity)
+
+	tx := r.db.
+		WithContext(ctx).
+		Table("(?) AS chats", withActivity)
+
+	if query.MemberID != "" {
+		tx = tx.Where("id IN (?)", memberChats(r.db, query.MemberID))
+	}
+	if query.Title != "" {
+		// LIKE ignores case of ASCII letters only.
+		tx = tx.Where(`title LIKE ? ESCAPE '\'`, "%"+escapeLike(query.Title)+"%")
+	}
+	if query.CreatedFrom != nil {
+		tx = tx.Where("created_at >= ?", *query.CreatedFrom)
+	}
+	if query.CreatedTo != nil {
+		tx = tx.Where("created_at <= ?", *query.CreatedTo)
+	}
+
+	sortColumn := "created_at"
+	if query.Sort == models.ChatSortLastActivity {
+		sortColumn = "last_activity"
+	}
+
+	cmp, direction := "<", "DESC"
+	if query.Order == models.SortOrderAsc {
+		cmp, direction = ">", "ASC"
+	}
+
+	if query.Cursor != nil {
+		tx = tx.Where("("+sortColumn+", id) "+cmp+" (?, ?)", query.Cursor.SortValue, query.Cursor.ID)
+	}
+
+	var rows []*sqliteChatRow
+
+	if err := tx.
+		Order(sortColumn + " " + direction + ", id " + direction).
+		Limit(query.Limit + 1).
+		Find(&rows).Error; err != nil {
+
+		return nil, err
+	}
+
+	hasMore := len(rows) > query.Limit
+	if hasMore {
+		rows = rows[:query.Limit]
+	}
+
+	chats := make([]*models.Chat, 0, len(rows))
+	for _, row := range rows {
+		lastActivity, err := time.Parse(sqliteTimeFormat, row.LastActivity)
+		if err != nil {
+			return nil, err
+		}
+		lastActivity = lastActivity.UTC()
+
+		chat := row.Chat
+		chat.LastActivityAt = &lastActivity
+		chats = append(chats, &chat)
+	}
+
+	return &models.ChatListResponse{
+		Chats:   chats,
+		HasMore: hasMore,
+	}, nil
+}
+
+func (r *SQLiteRepository) DeleteChat(ctx context.Context, chatId int) error {
+	result := r.db.
+		WithContext(ctx).
+		Delete(&models.Chat{}, chatId)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return suberrors.ErrChatNotFound
+	}
+
+	r.publish(&models.Event{Type: models.EventChatDeleted, ChatID: chatId})
+	return nil
+}
+
+func (r *SQLiteRepository) CreateMessage(ctx context.Context, chatId int, message *models.Message) (*models.Message, error) {
+	message.ChatID = chatId
+
+	if err := r.db.
+		WithContext(ctx).
+		Create(message).Error; err != nil {
+
+		if isSQLiteForeignKeyViolation(err) {
+			return nil, suberrors.ErrChatNotFound
+		}
+		return nil, err
+	}
+
+	r.publish(&models.Event{Type: models.EventMessageCreated, ChatID: chatId, Message: copyMessage(message)})
+	return message, nil
+}
+
+func (r *SQLiteRepository) CreateMessages(ctx context.Context, chatId int, messages []*models.Message) ([]*models.Message, error) {
+	for _, message := range messages {
+		message.ChatID = chatId
+	}
+
+	if err := r.db.
+		WithContext(ctx).
+		Create(messages).Error; err != nil {
+
+		if isSQLiteForeignKeyViolation(err) {
+			return nil, suberrors.ErrChatNotFound
+		}
+		return nil, err
+	}
+
+	for _, message := range messages {
+		r.publish(&models.Event{Type: models.EventMessageCreated, ChatID: chatId, Message: copyMessage(message)})
+	}
+	return messages, nil
+}
+
+// Search matches whole words, ignoring case: every word of the query must
+// occur in the message, and none of the words prefixed with "-".
+func (r *SQLiteRepository) Search(ctx context.Context, query *models.SearchQuery) (*models.SearchResponse, error) {
+	if query.ChatID > 0 {
+		if err := r.ChatExists(ctx, query.ChatID); err != nil {
+			return nil, err
+		}
+	}
+
+	include, exclude := parseSearchQuery(query.Query)
+	if len(include) == 0 {
+		return &models.SearchResponse{Results: []*models.SearchResult{}}, nil
+	}
+
+	// bm25 ranks better matches lower.
+	tx := r.db.
+		WithContext(ctx).
+		Table("messages_fts").
+		Select("messages.*, -bm25(messages_fts) AS rank").
+		Joins("JOIN messages ON messages.id = messages_fts.rowid").
+		Where("messages_fts MATCH ? AND messages.deleted_at IS NULL", ftsQuery(include, exclude))
+
+	if query.ChatID > 0 {
+		tx = tx.Where("messages.chat_id = ?", query.ChatID)
+	}
+	if query.MemberID != "" {
+		tx = tx.Where("messages.chat_id IN (?)", memberChats(r.db, query.MemberID))
+	}
+	if query.Cursor != nil {
+		tx = tx.Offset(query.Cursor.Offset)
+	}
+
+	var rows []*searchRow
+
+	// One extra row tells us whether another page exists.
+	if err := tx.
+		Order("rank DESC, messages.id DESC").
+		Limit(query.Limit + 1).
+		Scan(&rows).Error; err != nil {
+
+		return nil, err
+	}
+
+	hasMore := len(rows) > query.Limit
+	if hasMore {
+		rows = rows[:query.Limit]
+	}
+
+	results := make([]*models.SearchResult, 0, len(rows))
+	for _, row := range rows {
+		message := row.Message
+		snippet, _ := markWords(stripSnippetMarks(message.Text), include)
+		results = append(results, &models.SearchResult{
+			Message: &message,
+			Rank:    row.Rank,
+			Snippet: highlightSnippet(snippet),
+		})
+	}
+
+	return &models.SearchResponse{
+		Results: results,
+		HasMore: hasMore,
+	}, nil
+}
+
+// AddChatMember adds a user to a chat, or changes the role of an existing member.
+func (r *SQLiteRepository) AddChatMember(ctx context.Context, member *models.ChatMember) (*models.ChatMember, error) {
+	member, err := r.HiTalentRepository.AddChatMember(ctx, member)
+	if isSQLiteForeignKeyViolation(err) {
+		return nil, suberrors.ErrChatNotFound
+	}
+	return member, err
+}
+
+func (r *SQLiteRepository) publish(event *models.Event) {
+	if r.publisher != nil {
+		r.publisher.Publish(event)
+	}
+}
+
+// isSQLiteForeignKeyViolation is the SQLite counterpart of Postgres error
+// 23503. SQLite reports it only with foreign keys enabled, as pkg/sqlite does.
+func isSQLiteForeignKeyViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY
+}
+
+// ftsQuery builds an FTS5 query from words parseSearchQuery returned, which
+// hold only letters and digits and so need no escaping inside quotes.
+func ftsQuery(include, exclude []string) string {
+	q := `("` + strings.Join(include, `" AND "`) + `")`
+	for _, w := range exclude {
+		q += ` NOT "` + w + `"`
+	}
+	return q
+}
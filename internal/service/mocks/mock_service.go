@@ -11,6 +11,7 @@ package mocks
 
 import (
 	models "TestHitalent/internal/models"
+	context "context"
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
@@ -40,61 +41,240 @@ func (m *MockHiTalentServiceInterface) EXPECT() *MockHiTalentServiceInterfaceMoc
 	return m.recorder
 }
 
+// AddChatMember mocks base method.
+func (m *MockHiTalentServiceInterface) AddChatMember(ctx context.Context, principal *models.Principal, chatId string, member *models.ChatMember) (*models.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddChatMember", ctx, principal, chatId, member)
+	ret0, _ := ret[0].(*models.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddChatMember indicates an expected call of AddChatMember.
+func (mr *MockHiTalentServiceInterfaceMockRecorder) AddChatMember(ctx, principal, chatId, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddChatMember", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).AddChatMember), ctx, principal, chatId, member)
+}
+
+// Authenticate mocks base method.
+func (m *MockHiTalentServiceInterface) Authenticate(ctx context.Context, credential string) (*models.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, credential)
+	ret0, _ := ret[0].(*models.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockHiTalentServiceInterfaceMockRecorder) Authenticate(ctx, credential any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).Authenticate), ctx, credential)
+}
+
 // CreateChat mocks base method.
-func (m *MockHiTalentServiceInterface) CreateChat(chat *models.Chat) (*models.Chat, error) {
+func (m *MockHiTalentServiceInterface) CreateChat(ctx context.Context, principal *models.Principal, chat *models.Chat) (*models.Chat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateChat", chat)
+	ret := m.ctrl.Call(m, "CreateChat", ctx, principal, chat)
 	ret0, _ := ret[0].(*models.Chat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateChat indicates an expected call of CreateChat.
-func (mr *MockHiTalentServiceInterfaceMockRecorder) CreateChat(chat any) *gomock.Call {
+func (mr *MockHiTalentServiceInterfaceMockRecorder) CreateChat(ctx, principal, chat any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChat", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).CreateChat), chat)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateChat", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).CreateChat), ctx, principal, chat)
 }
 
 // CreateMessage mocks base method.
-func (m *MockHiTalentServiceInterface) CreateMessage(chatId string, message *models.Message) (*models.Message, error) {
+func (m *MockHiTalentServiceInterface) CreateMessage(ctx context.Context, principal *models.Principal, chatId string, message *models.Message) (*models.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateMessage", chatId, message)
+	ret := m.ctrl.Call(m, "CreateMessage", ctx, principal, chatId, message)
 	ret0, _ := ret[0].(*models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateMessage indicates an expected call of CreateMessage.
-func (mr *MockHiTalentServiceInterfaceMockRecorder) CreateMessage(chatId, message any) *gomock.Call {
+func (mr *MockHiTalentServiceInterfaceMockRecorder) CreateMessage(ctx, principal, chatId, message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).CreateMessage), ctx, principal, chatId, message)
+}
+
+// CreateMessageBatch mocks base method.
+func (m *MockHiTalentServiceInterface) CreateMessageBatch(ctx context.Context, principal *models.Principal, chatId string, messages []*models.Message) ([]*models.MessageBatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMessageBatch", ctx, principal, chatId, messages)
+	ret0, _ := ret[0].([]*models.MessageBatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMessageBatch indicates an expected call of CreateMessageBatch.
+func (mr *MockHiTalentServiceInterfaceMockRecorder) CreateMessageBatch(ctx, principal, chatId, messages any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).CreateMessage), chatId, message)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessageBatch", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).CreateMessageBatch), ctx, principal, chatId, messages)
 }
 
 // DeleteChat mocks base method.
-func (m *MockHiTalentServiceInterface) DeleteChat(chatId string) error {
+func (m *MockHiTalentServiceInterface) DeleteChat(ctx context.Context, principal *models.Principal, chatId string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteChat", chatId)
+	ret := m.ctrl.Call(m, "DeleteChat", ctx, principal, chatId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteChat indicates an expected call of DeleteChat.
-func (mr *MockHiTalentServiceInterfaceMockRecorder) DeleteChat(chatId any) *gomock.Call {
+func (mr *MockHiTalentServiceInterfaceMockRecorder) DeleteChat(ctx, principal, chatId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChat", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).DeleteChat), chatId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteChat", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).DeleteChat), ctx, principal, chatId)
+}
+
+// DeleteMessage mocks base method.
+func (m *MockHiTalentServiceInterface) DeleteMessage(ctx context.Context, principal *models.Principal, chatId, messageId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMessage", ctx, principal, chatId, messageId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMessage indicates an expected call of DeleteMessage.
+func (mr *MockHiTalentServiceInterfaceMockRecorder) DeleteMessage(ctx, principal, chatId, messageId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessage", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).DeleteMessage), ctx, principal, chatId, messageId)
 }
 
 // GetChat mocks base method.
-func (m *MockHiTalentServiceInterface) GetChat(chatId string, limit int) (*models.ChatAndMessagesResponse, error) {
+func (m *MockHiTalentServiceInterface) GetChat(ctx context.Context, principal *models.Principal, chatId string, query *models.MessagesQuery) (*models.ChatAndMessagesResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetChat", chatId, limit)
+	ret := m.ctrl.Call(m, "GetChat", ctx, principal, chatId, query)
 	ret0, _ := ret[0].(*models.ChatAndMessagesResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetChat indicates an expected call of GetChat.
-func (mr *MockHiTalentServiceInterfaceMockRecorder) GetChat(chatId, limit any) *gomock.Call {
+func (mr *MockHiTalentServiceInterfaceMockRecorder) GetChat(ctx, principal, chatId, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChat", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).GetChat), ctx, principal, chatId, query)
+}
+
+// ListChatMembers mocks base method.
+func (m *MockHiTalentServiceInterface) ListChatMembers(ctx context.Context, principal *models.Principal, chatId string) ([]*models.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChatMembers", ctx, principal, chatId)
+	ret0, _ := ret[0].([]*models.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChatMembers indicates an expected call of ListChatMembers.
+func (mr *MockHiTalentServiceInterfaceMockRecorder) ListChatMembers(ctx, principal, chatId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChatMembers", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).ListChatMembers), ctx, principal, chatId)
+}
+
+// ListChats mocks base method.
+func (m *MockHiTalentServiceInterface) ListChats(ctx context.Context, principal *models.Principal, query *models.ChatsQuery) (*models.ChatListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChats", ctx, principal, query)
+	ret0, _ := ret[0].(*models.ChatListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChats indicates an expected call of ListChats.
+func (mr *MockHiTalentServiceInterfaceMockRecorder) ListChats(ctx, principal, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChats", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).ListChats), ctx, principal, query)
+}
+
+// RemoveChatMember mocks base method.
+func (m *MockHiTalentServiceInterface) RemoveChatMember(ctx context.Context, principal *models.Principal, chatId, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveChatMember", ctx, principal, chatId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveChatMember indicates an expected call of RemoveChatMember.
+func (mr *MockHiTalentServiceInterfaceMockRecorder) RemoveChatMember(ctx, principal, chatId, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveChatMember", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).RemoveChatMember), ctx, principal, chatId, userId)
+}
+
+// Search mocks base method.
+func (m *MockHiTalentServiceInterface) Search(ctx context.Context, principal *models.Principal, query *models.SearchQuery) (*models.SearchResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, principal, query)
+	ret0, _ := ret[0].(*models.SearchResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockHiTalentServiceInterfaceMockRecorder) Search(ctx, principal, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).Search), ctx, principal, query)
+}
+
+// SearchChat mocks base method.
+func (m *MockHiTalentServiceInterface) SearchChat(ctx context.Context, principal *models.Principal, chatId string, query *models.SearchQuery) (*models.SearchResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchChat", ctx, principal, chatId, query)
+	ret0, _ := ret[0].(*models.SearchResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchChat indicates an expected call of SearchChat.
+func (mr *MockHiTalentServiceInterfaceMockRecorder) SearchChat(ctx, principal, chatId, query any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchChat", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).SearchChat), ctx, principal, chatId, query)
+}
+
+// Subscribe mocks base method.
+func (m *MockHiTalentServiceInterface) Subscribe(ctx context.Context, principal *models.Principal, chatId string, lastEventId int) (<-chan *models.Event, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, principal, chatId, lastEventId)
+	ret0, _ := ret[0].(<-chan *models.Event)
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockHiTalentServiceInterfaceMockRecorder) Subscribe(ctx, principal, chatId, lastEventId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).Subscribe), ctx, principal, chatId, lastEventId)
+}
+
+// UpdateChat mocks base method.
+func (m *MockHiTalentServiceInterface) UpdateChat(ctx context.Context, principal *models.Principal, chatId string, chat *models.Chat, version int) (*models.Chat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateChat", ctx, principal, chatId, chat, version)
+	ret0, _ := ret[0].(*models.Chat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateChat indicates an expected call of UpdateChat.
+func (mr *MockHiTalentServiceInterfaceMockRecorder) UpdateChat(ctx, principal, chatId, chat, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateChat", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).UpdateChat), ctx, principal, chatId, chat, version)
+}
+
+// UpdateMessage mocks base method.
+func (m *MockHiTalentServiceInterface) UpdateMessage(ctx context.Context, principal *models.Principal, chatId, messageId string, message *models.Message) (*models.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMessage", ctx, principal, chatId, messageId, message)
+	ret0, _ := ret[0].(*models.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateMessage indicates an expected call of UpdateMessage.
+func (mr *MockHiTalentServiceInterfaceMockRecorder) UpdateMessage(ctx, principal, chatId, messageId, message any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChat", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).GetChat), chatId, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessage", reflect.TypeOf((*MockHiTalentServiceInterface)(nil).UpdateMessage), ctx, principal, chatId, messageId, message)
 }
@@ -1,12 +1,18 @@
 package service
 
 import (
+	"TestHitalent/internal/auth"
 	"TestHitalent/internal/models"
 	"TestHitalent/pkg/suberrors"
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -14,41 +20,264 @@ import (
 //go:generate mockgen -source=service.go -destination=../repository/mocks/mock_repository.go -package=mocks HiTalentRepositoryInterface
 
 type HiTalentRepositoryInterface interface {
-	CreateChat(chat *models.Chat) (*models.Chat, error)
-	GetChat(chatId int, limit int) (*models.ChatAndMessagesResponse, error)
-	CreateMessage(chatId int, message *models.Message) (*models.Message, error)
-	DeleteChat(chatId int) error
+	CreateChat(ctx context.Context, chat *models.Chat, ownerId string) (*models.Chat, error)
+	GetChat(ctx context.Context, chatId int, query *models.MessagesQuery) (*models.ChatAndMessagesResponse, error)
+	ChatExists(ctx context.Context, chatId int) error
+	ListChats(ctx context.Context, query *models.ChatsQuery) (*models.ChatListResponse, error)
+	UpdateChat(ctx context.Context, chatId int, title string, version int) (*models.Chat, error)
+	CreateMessage(ctx context.Context, chatId int, message *models.Message) (*models.Message, error)
+	CreateMessages(ctx context.Context, chatId int, messages []*models.Message) ([]*models.Message, error)
+	DeleteChat(ctx context.Context, chatId int) error
+	UpdateMessage(ctx context.Context, chatId int, messageId int, text string) (*models.Message, error)
+	DeleteMessage(ctx context.Context, chatId int, messageId int) error
+	ListMessagesAfter(ctx context.Context, chatId int, afterId int, limit int) ([]*models.Message, error)
+	Search(ctx context.Context, query *models.SearchQuery) (*models.SearchResponse, error)
+	GetAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error)
+	GetChatRole(ctx context.Context, chatId int, userId string) (string, error)
+	ListChatMembers(ctx context.Context, chatId int) ([]*models.ChatMember, error)
+	AddChatMember(ctx context.Context, member *models.ChatMember) (*models.ChatMember, error)
+	RemoveChatMember(ctx context.Context, chatId int, userId string) error
+	CountChatOwners(ctx context.Context, chatId int) (int, error)
+	GetMessage(ctx context.Context, chatId int, messageId int) (*models.Message, error)
 }
 
+// EventBroker delivers the chat events the repository announces on every write.
+type EventBroker interface {
+	Subscribe(chatId int) (<-chan *models.Event, func())
+}
+
+type TokenVerifier interface {
+	Verify(token string) (*models.Principal, error)
+}
+
+var (
+	readRoles  = []string{models.ChatRoleOwner, models.ChatRoleMember, models.ChatRoleReadOnly}
+	writeRoles = []string{models.ChatRoleOwner, models.ChatRoleMember}
+	ownerRoles = []string{models.ChatRoleOwner}
+)
+
+// replayPageSize bounds each storage read while catching a resumed
+// subscriber up on missed messages.
+const replayPageSize = 100
+
 type HiTalentService struct {
 	repo     HiTalentRepositoryInterface
-	ctx      context.Context
+	broker   EventBroker
+	verifier TokenVerifier
 	validate *validator.Validate
 }
 
-func NewHiTalentService(ctx context.Context, repo HiTalentRepositoryInterface) *HiTalentService {
+func NewHiTalentService(repo HiTalentRepositoryInterface, broker EventBroker, verifier TokenVerifier) *HiTalentService {
 	return &HiTalentService{
 		repo:     repo,
-		ctx:      ctx,
-		validate: validator.New(),
+		broker:   broker,
+		verifier: verifier,
+		validate: newValidator(),
 	}
 }
 
-func (s *HiTalentService) CreateChat(chat *models.Chat) (*models.Chat, error) {
+// newValidator reports fields by their JSON names, which is what API clients
+// see in validation errors.
+func newValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return validate
+}
+
+// Authenticate resolves a credential, either an API key or a JWT, to the
+// caller it belongs to.
+func (s *HiTalentService) Authenticate(ctx context.Context, credential string) (*models.Principal, error) {
+	credential = strings.TrimSpace(credential)
+	if credential == "" {
+		return nil, suberrors.ErrUnauthorized
+	}
+
+	if strings.HasPrefix(credential, auth.APIKeyPrefix) {
+		key, err := s.repo.GetAPIKey(ctx, auth.HashAPIKey(credential))
+		if err != nil {
+			if errors.Is(err, suberrors.ErrAPIKeyNotFound) {
+				return nil, suberrors.ErrUnauthorized
+			}
+			return nil, err
+		}
+		return &models.Principal{
+			ID:     models.AuthMethodAPIKey + ":" + strconv.Itoa(key.ID),
+			Name:   key.Name,
+			Method: models.AuthMethodAPIKey,
+		}, nil
+	}
+
+	if s.verifier == nil {
+		return nil, suberrors.ErrUnauthorized
+	}
+
+	principal, err := s.verifier.Verify(credential)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", suberrors.ErrUnauthorized, err)
+	}
+
+	return principal, nil
+}
+
+// CreateChat creates a chat owned by the principal, together with its
+// initial messages, if any. The principal is their sender, as when posting.
+func (s *HiTalentService) CreateChat(ctx context.Context, principal *models.Principal, chat *models.Chat) (*models.Chat, error) {
+	if principal == nil {
+		return nil, suberrors.ErrUnauthorized
+	}
 	if chat == nil {
 		return nil, errors.New("chat is nil")
 	}
 
 	chat.Title = strings.TrimSpace(chat.Title)
+	for _, message := range chat.Messages {
+		if message == nil {
+			continue
+		}
+		message.Text = strings.TrimSpace(message.Text)
+		setSender(principal, message)
+		clearStoredFields(message)
+		// Initial messages are posted with the chat, not imported.
+		message.CreatedAt = time.Time{}
+	}
 
 	if err := s.validate.Struct(chat); err != nil {
 		return nil, err
 	}
 
-	return s.repo.CreateChat(chat)
+	return s.repo.CreateChat(ctx, chat, principal.ID)
+}
+
+func (s *HiTalentService) GetChat(ctx context.Context, principal *models.Principal, chatId string, query *models.MessagesQuery) (*models.ChatAndMessagesResponse, error) {
+	chatID, err := strconv.Atoi(chatId)
+	if err != nil {
+		return nil, suberrors.ErrInvalidChatId
+	}
+	if chatID <= 0 {
+		return nil, suberrors.ErrNotPositiveChatId
+	}
+	if query == nil {
+		return nil, errors.New("query is nil")
+	}
+	if query.Before != nil && query.After != nil {
+		return nil, suberrors.ErrInvalidCursor
+	}
+	if err = s.authorize(ctx, principal, chatID, readRoles); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.repo.GetChat(ctx, chatID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	hideDeleted(resp.Messages)
+
+	if resp.HasMore && len(resp.Messages) > 0 {
+		// Messages are always newest first, so the cursor continuing the
+		// requested direction is the oldest message for "before" pages and
+		// the newest one for "after" pages.
+		last := resp.Messages[len(resp.Messages)-1]
+		if query.After != nil {
+			last = resp.Messages[0]
+		}
+		resp.NextCursor = models.NewMessageCursor(last).Encode()
+	}
+
+	return resp, nil
+}
+
+// ListChats lists the chats the principal is a member of.
+func (s *HiTalentService) ListChats(ctx context.Context, principal *models.Principal, query *models.ChatsQuery) (*models.ChatListResponse, error) {
+	if principal == nil {
+		return nil, suberrors.ErrUnauthorized
+	}
+	if query == nil {
+		return nil, errors.New("query is nil")
+	}
+
+	switch query.Sort {
+	case "":
+		query.Sort = models.ChatSortCreatedAt
+	case models.ChatSortCreatedAt, models.ChatSortLastActivity:
+	default:
+		return nil, suberrors.ErrInvalidSort
+	}
+
+	switch query.Order {
+	case "":
+		query.Order = models.SortOrderDesc
+	case models.SortOrderAsc, models.SortOrderDesc:
+	default:
+		return nil, suberrors.ErrInvalidSortOrder
+	}
+
+	if query.CreatedFrom != nil && query.CreatedTo != nil && query.CreatedFrom.After(*query.CreatedTo) {
+		return nil, suberrors.ErrInvalidTimeRange
+	}
+	if query.Cursor != nil && (query.Cursor.Sort != query.Sort || query.Cursor.Order != query.Order) {
+		return nil, suberrors.ErrInvalidCursor
+	}
+
+	query.Title = strings.TrimSpace(query.Title)
+	query.MemberID = memberFilter(principal)
+
+	resp, err := s.repo.ListChats(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.HasMore && len(resp.Chats) > 0 {
+		last := resp.Chats[len(resp.Chats)-1]
+		cursor := &models.ChatCursor{Sort: query.Sort, Order: query.Order, SortValue: last.CreatedAt, ID: last.ID}
+		if query.Sort == models.ChatSortLastActivity && last.LastActivityAt != nil {
+			cursor.SortValue = *last.LastActivityAt
+		}
+		resp.NextCursor = cursor.Encode()
+	}
+
+	return resp, nil
+}
+
+// UpdateChat renames a chat. A positive version makes the update
+// conditional on the chat still being at that version; zero, sent as
+// "If-Match: *", is an explicit request to skip the check.
+func (s *HiTalentService) UpdateChat(ctx context.Context, principal *models.Principal, chatId string, chat *models.Chat, version int) (*models.Chat, error) {
+	chatID, err := strconv.Atoi(chatId)
+	if err != nil {
+		return nil, suberrors.ErrInvalidChatId
+	}
+	if chatID <= 0 {
+		return nil, suberrors.ErrNotPositiveChatId
+	}
+	if version < 0 {
+		return nil, suberrors.ErrInvalidChatVersion
+	}
+
+	if chat == nil {
+		return nil, errors.New("chat is nil")
+	}
+
+	chat.Title = strings.TrimSpace(chat.Title)
+
+	if err = s.validate.Struct(chat); err != nil {
+		return nil, err
+	}
+
+	if err = s.authorize(ctx, principal, chatID, ownerRoles); err != nil {
+		return nil, err
+	}
+
+	return s.repo.UpdateChat(ctx, chatID, chat.Title, version)
 }
 
-func (s *HiTalentService) GetChat(chatId string, limit int) (*models.ChatAndMessagesResponse, error) {
+func (s *HiTalentService) CreateMessage(ctx context.Context, principal *models.Principal, chatId string, message *models.Message) (*models.Message, error) {
 	chatID, err := strconv.Atoi(chatId)
 	if err != nil {
 		return nil, suberrors.ErrInvalidChatId
@@ -56,10 +285,112 @@ func (s *HiTalentService) GetChat(chatId string, limit int) (*models.ChatAndMess
 	if chatID <= 0 {
 		return nil, suberrors.ErrNotPositiveChatId
 	}
-	return s.repo.GetChat(chatID, limit)
+
+	if message == nil {
+		return nil, errors.New("message is nil")
+	}
+
+	message.Text = strings.TrimSpace(message.Text)
+	setSender(principal, message)
+	clearStoredFields(message)
+	// A message is posted when it is stored.
+	message.CreatedAt = time.Time{}
+
+	if err = s.validate.Struct(message); err != nil {
+		return nil, err
+	}
+
+	if err = s.authorize(ctx, principal, chatID, writeRoles); err != nil {
+		return nil, err
+	}
+
+	return s.repo.CreateMessage(ctx, chatID, message)
+}
+
+// CreateMessageBatch imports messages into a chat, keeping the time each was
+// originally sent at when given. Messages that fail validation are reported
+// in their results and skipped; the rest are stored in one transaction.
+func (s *HiTalentService) CreateMessageBatch(ctx context.Context, principal *models.Principal, chatId string, messages []*models.Message) ([]*models.MessageBatchResult, error) {
+	chatID, err := strconv.Atoi(chatId)
+	if err != nil {
+		return nil, suberrors.ErrInvalidChatId
+	}
+	if chatID <= 0 {
+		return nil, suberrors.ErrNotPositiveChatId
+	}
+
+	if len(messages) == 0 {
+		return nil, suberrors.ErrEmptyMessageBatch
+	}
+	if len(messages) > models.MaxMessageBatch {
+		return nil, suberrors.ErrMessageBatchTooLarge
+	}
+
+	if err = s.authorize(ctx, principal, chatID, writeRoles); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := make([]*models.MessageBatchResult, len(messages))
+	valid := make([]*models.Message, 0, len(messages))
+	for i, message := range messages {
+		results[i] = &models.MessageBatchResult{Index: i}
+		if err := s.validateImported(principal, message, now); err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Message = message
+		valid = append(valid, message)
+	}
+
+	if len(valid) > 0 {
+		if _, err := s.repo.CreateMessages(ctx, chatID, valid); err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
 }
 
-func (s *HiTalentService) CreateMessage(chatId string, message *models.Message) (*models.Message, error) {
+// validateImported checks a message of a batch like CreateMessage does, and
+// that it was not sent later than now.
+func (s *HiTalentService) validateImported(principal *models.Principal, message *models.Message, now time.Time) error {
+	if message == nil {
+		return suberrors.ErrMessageRequired
+	}
+
+	message.Text = strings.TrimSpace(message.Text)
+	setSender(principal, message)
+	// An export carries ids of the system it came from; they are not ours.
+	clearStoredFields(message)
+
+	if err := s.validate.Struct(message); err != nil {
+		return err
+	}
+
+	if message.CreatedAt.After(now) {
+		return suberrors.ErrMessageFromFuture
+	}
+	// Storage keeps microseconds; truncating here makes every backend agree.
+	message.CreatedAt = message.CreatedAt.UTC().Truncate(time.Microsecond)
+	return nil
+}
+
+func (s *HiTalentService) DeleteChat(ctx context.Context, principal *models.Principal, chatId string) error {
+	chatID, err := strconv.Atoi(chatId)
+	if err != nil {
+		return suberrors.ErrInvalidChatId
+	}
+	if chatID <= 0 {
+		return suberrors.ErrNotPositiveChatId
+	}
+	if err = s.authorize(ctx, principal, chatID, ownerRoles); err != nil {
+		return err
+	}
+	return s.repo.DeleteChat(ctx, chatID)
+}
+
+func (s *HiTalentService) UpdateMessage(ctx context.Context, principal *models.Principal, chatId string, messageId string, message *models.Message) (*models.Message, error) {
 	chatID, err := strconv.Atoi(chatId)
 	if err != nil {
 		return nil, suberrors.ErrInvalidChatId
@@ -68,6 +399,14 @@ func (s *HiTalentService) CreateMessage(chatId string, message *models.Message)
 		return nil, suberrors.ErrNotPositiveChatId
 	}
 
+	messageID, err := strconv.Atoi(messageId)
+	if err != nil {
+		return nil, suberrors.ErrInvalidMessageId
+	}
+	if messageID <= 0 {
+		return nil, suberrors.ErrNotPositiveMessageId
+	}
+
 	if message == nil {
 		return nil, errors.New("message is nil")
 	}
@@ -78,10 +417,239 @@ func (s *HiTalentService) CreateMessage(chatId string, message *models.Message)
 		return nil, err
 	}
 
-	return s.repo.CreateMessage(chatID, message)
+	if err = s.authorizeAuthor(ctx, principal, chatID, messageID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.UpdateMessage(ctx, chatID, messageID, message.Text)
+}
+
+func (s *HiTalentService) DeleteMessage(ctx context.Context, principal *models.Principal, chatId string, messageId string) error {
+	chatID, err := strconv.Atoi(chatId)
+	if err != nil {
+		return suberrors.ErrInvalidChatId
+	}
+	if chatID <= 0 {
+		return suberrors.ErrNotPositiveChatId
+	}
+
+	messageID, err := strconv.Atoi(messageId)
+	if err != nil {
+		return suberrors.ErrInvalidMessageId
+	}
+	if messageID <= 0 {
+		return suberrors.ErrNotPositiveMessageId
+	}
+
+	if err = s.authorizeAuthor(ctx, principal, chatID, messageID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteMessage(ctx, chatID, messageID)
+}
+
+// Search looks for messages in the chats the principal is a member of.
+func (s *HiTalentService) Search(ctx context.Context, principal *models.Principal, query *models.SearchQuery) (*models.SearchResponse, error) {
+	if principal == nil {
+		return nil, suberrors.ErrUnauthorized
+	}
+	if query == nil {
+		return nil, errors.New("query is nil")
+	}
+
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" {
+		return nil, suberrors.ErrEmptySearchQuery
+	}
+
+	query.MemberID = memberFilter(principal)
+
+	resp, err := s.repo.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.HasMore {
+		offset := len(resp.Results)
+		if query.Cursor != nil {
+			offset += query.Cursor.Offset
+		}
+		resp.NextCursor = (&models.SearchCursor{Offset: offset}).Encode()
+	}
+
+	return resp, nil
+}
+
+// SearchChat is Search limited to the messages of one chat.
+func (s *HiTalentService) SearchChat(ctx context.Context, principal *models.Principal, chatId string, query *models.SearchQuery) (*models.SearchResponse, error) {
+	chatID, err := strconv.Atoi(chatId)
+	if err != nil {
+		return nil, suberrors.ErrInvalidChatId
+	}
+	if chatID <= 0 {
+		return nil, suberrors.ErrNotPositiveChatId
+	}
+	if query == nil {
+		return nil, errors.New("query is nil")
+	}
+
+	if err = s.authorize(ctx, principal, chatID, readRoles); err != nil {
+		return nil, err
+	}
+
+	query.ChatID = chatID
+
+	return s.Search(ctx, principal, query)
+}
+
+// Subscribe streams events of a chat until the returned stop function is
+// called. When lastEventId is positive, messages created after it are
+// replayed from storage before live events, without duplicates.
+func (s *HiTalentService) Subscribe(ctx context.Context, principal *models.Principal, chatId string, lastEventId int) (<-chan *models.Event, func(), error) {
+	chatID, err := strconv.Atoi(chatId)
+	if err != nil {
+		return nil, nil, suberrors.ErrInvalidChatId
+	}
+	if chatID <= 0 {
+		return nil, nil, suberrors.ErrNotPositiveChatId
+	}
+	if lastEventId < 0 {
+		return nil, nil, suberrors.ErrInvalidEventId
+	}
+	if s.broker == nil {
+		return nil, nil, errors.New("event broker is not configured")
+	}
+	if err = s.authorize(ctx, principal, chatID, readRoles); err != nil {
+		return nil, nil, err
+	}
+	// Membership lookups already fail for missing chats; API keys skip them.
+	if principal.Method == models.AuthMethodAPIKey {
+		if err = s.repo.ChatExists(ctx, chatID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Subscribe before reading the backlog so nothing created in between is lost.
+	live, cancel := s.broker.Subscribe(chatID)
+
+	var backlog []*models.Message
+	if lastEventId > 0 {
+		afterID := lastEventId
+		for {
+			page, err := s.repo.ListMessagesAfter(ctx, chatID, afterID, replayPageSize)
+			if err != nil {
+				cancel()
+				return nil, nil, err
+			}
+			backlog = append(backlog, page...)
+			if len(page) < replayPageSize {
+				break
+			}
+			afterID = page[len(page)-1].ID
+		}
+		hideDeleted(backlog)
+	}
+
+	out := make(chan *models.Event)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(out)
+
+		lastID := lastEventId
+		for _, message := range backlog {
+			select {
+			case out <- &models.Event{Type: models.EventMessageCreated, ChatID: chatID, Message: message}:
+				lastID = message.ID
+			case <-done:
+				return
+			}
+		}
+
+		for {
+			select {
+			case event, ok := <-live:
+				if !ok {
+					return
+				}
+				if event.Message != nil && event.Message.ID <= lastID {
+					continue
+				}
+				select {
+				case out <- event:
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(done)
+			cancel()
+		})
+	}
+
+	return out, stop, nil
+}
+
+func (s *HiTalentService) ListChatMembers(ctx context.Context, principal *models.Principal, chatId string) ([]*models.ChatMember, error) {
+	chatID, err := strconv.Atoi(chatId)
+	if err != nil {
+		return nil, suberrors.ErrInvalidChatId
+	}
+	if chatID <= 0 {
+		return nil, suberrors.ErrNotPositiveChatId
+	}
+	if err = s.authorize(ctx, principal, chatID, readRoles); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListChatMembers(ctx, chatID)
 }
 
-func (s *HiTalentService) DeleteChat(chatId string) error {
+// AddChatMember adds a user to a chat or changes their role. Only owners
+// manage members.
+func (s *HiTalentService) AddChatMember(ctx context.Context, principal *models.Principal, chatId string, member *models.ChatMember) (*models.ChatMember, error) {
+	chatID, err := strconv.Atoi(chatId)
+	if err != nil {
+		return nil, suberrors.ErrInvalidChatId
+	}
+	if chatID <= 0 {
+		return nil, suberrors.ErrNotPositiveChatId
+	}
+
+	if member == nil {
+		return nil, errors.New("member is nil")
+	}
+
+	member.ChatID = chatID
+	member.UserID = strings.TrimSpace(member.UserID)
+
+	if err = s.validate.Struct(member); err != nil {
+		return nil, err
+	}
+
+	if err = s.authorize(ctx, principal, chatID, ownerRoles); err != nil {
+		return nil, err
+	}
+
+	if member.Role != models.ChatRoleOwner {
+		if err = s.keepOwner(ctx, chatID, member.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.AddChatMember(ctx, member)
+}
+
+// RemoveChatMember removes a user from a chat. Owners remove anyone, other
+// members may only leave the chat themselves.
+func (s *HiTalentService) RemoveChatMember(ctx context.Context, principal *models.Principal, chatId string, userId string) error {
 	chatID, err := strconv.Atoi(chatId)
 	if err != nil {
 		return suberrors.ErrInvalidChatId
@@ -89,5 +657,135 @@ func (s *HiTalentService) DeleteChat(chatId string) error {
 	if chatID <= 0 {
 		return suberrors.ErrNotPositiveChatId
 	}
-	return s.repo.DeleteChat(chatID)
+
+	roles := ownerRoles
+	if principal != nil && principal.ID == userId {
+		roles = readRoles
+	}
+	if err = s.authorize(ctx, principal, chatID, roles); err != nil {
+		return err
+	}
+
+	if err = s.keepOwner(ctx, chatID, userId); err != nil {
+		return err
+	}
+
+	return s.repo.RemoveChatMember(ctx, chatID, userId)
+}
+
+// setSender records who wrote the message. Users always write as themselves;
+// API keys belong to integrations that relay messages on behalf of others, so
+// an explicit sender in the request is kept and the key is used otherwise.
+func setSender(principal *models.Principal, message *models.Message) {
+	message.SenderID = strings.TrimSpace(message.SenderID)
+	message.SenderName = strings.TrimSpace(message.SenderName)
+
+	if principal == nil {
+		return
+	}
+	if principal.Method == models.AuthMethodAPIKey && message.SenderID != "" {
+		return
+	}
+
+	message.SenderID = principal.ID
+	message.SenderName = principal.Name
+}
+
+// clearStoredFields drops the fields storage assigns, which a request body
+// may carry, so a client can neither pick a message's id nor post it
+// already edited or deleted.
+func clearStoredFields(message *models.Message) {
+	message.ID = 0
+	message.ChatID = 0
+	message.EditedAt = nil
+	message.DeletedAt = nil
+}
+
+// authorize checks that the principal holds one of the roles in the chat.
+func (s *HiTalentService) authorize(ctx context.Context, principal *models.Principal, chatID int, roles []string) error {
+	_, err := s.chatRole(ctx, principal, chatID, roles)
+	return err
+}
+
+// chatRole returns the principal's role in the chat if it is one of roles.
+// API keys are service credentials issued by operators and act as owners of
+// every chat.
+func (s *HiTalentService) chatRole(ctx context.Context, principal *models.Principal, chatID int, roles []string) (string, error) {
+	if principal == nil {
+		return "", suberrors.ErrUnauthorized
+	}
+	if principal.Method == models.AuthMethodAPIKey {
+		return models.ChatRoleOwner, nil
+	}
+
+	role, err := s.repo.GetChatRole(ctx, chatID, principal.ID)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(roles, role) {
+		return "", suberrors.ErrForbidden
+	}
+
+	return role, nil
+}
+
+// authorizeAuthor lets writers change only their own messages; owners
+// moderate every message in the chat.
+func (s *HiTalentService) authorizeAuthor(ctx context.Context, principal *models.Principal, chatID int, messageID int) error {
+	role, err := s.chatRole(ctx, principal, chatID, writeRoles)
+	if err != nil {
+		return err
+	}
+	if role == models.ChatRoleOwner {
+		return nil
+	}
+
+	message, err := s.repo.GetMessage(ctx, chatID, messageID)
+	if err != nil {
+		return err
+	}
+	if message.SenderID != principal.ID {
+		return suberrors.ErrForbidden
+	}
+
+	return nil
+}
+
+// keepOwner rejects removing or demoting the user when they are the chat's
+// last owner, since nobody could manage the chat afterwards.
+func (s *HiTalentService) keepOwner(ctx context.Context, chatID int, userId string) error {
+	role, err := s.repo.GetChatRole(ctx, chatID, userId)
+	if err != nil {
+		return err
+	}
+	if role != models.ChatRoleOwner {
+		return nil
+	}
+
+	owners, err := s.repo.CountChatOwners(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return suberrors.ErrLastOwner
+	}
+
+	return nil
+}
+
+// memberFilter restricts listings to the principal's chats, except for API
+// keys, which see every chat.
+func memberFilter(principal *models.Principal) string {
+	if principal.Method == models.AuthMethodAPIKey {
+		return ""
+	}
+	return principal.ID
+}
+
+func hideDeleted(messages []*models.Message) {
+	for _, message := range messages {
+		if message.DeletedAt != nil {
+			message.Text = models.DeletedMessagePlaceholder
+		}
+	}
 }
@@ -1,17 +1,29 @@
 package service
 
 import (
+	"TestHitalent/internal/auth"
+	"TestHitalent/internal/events"
 	"TestHitalent/internal/models"
 	"TestHitalent/internal/repository/mocks"
+	"TestHitalent/pkg/suberrors"
 	"context"
+	"errors"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/mock/gomock"
 )
 
+// operator authenticates with an API key, so membership checks do not apply.
+var operator = &models.Principal{ID: "api_key:1", Name: "ci", Method: models.AuthMethodAPIKey}
+
 func TestHiTalentService_CreateChatSuccess(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
@@ -25,13 +37,46 @@ func TestHiTalentService_CreateChatSuccess(t *testing.T) {
 		Title:     "Test title 1",
 		CreatedAt: time.Now(),
 	}
-	repo.EXPECT().CreateChat(ch).Return(expResp, nil).Times(1)
-	srv := NewHiTalentService(context.Background(), repo)
-	chat, err := srv.CreateChat(ch)
+	repo.EXPECT().CreateChat(gomock.Any(), ch, operator.ID).Return(expResp, nil).Times(1)
+	srv := NewHiTalentService(repo, nil, nil)
+	chat, err := srv.CreateChat(context.Background(), operator, ch)
 	require.NoError(t, err)
 	require.Equal(t, expResp, chat)
 }
 
+func TestHiTalentService_CreateChatWithMessages(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	user := &models.Principal{ID: "42", Name: "Alice", Method: models.AuthMethodJWT}
+	sentAt := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	ch := &models.Chat{
+		Title: "Onboarding",
+		Messages: []*models.Message{
+			{Text: " Welcome "},
+			{ID: 99, ChatID: 7, SenderID: "7", SenderName: "Bob", Text: "Read the rules", CreatedAt: sentAt, EditedAt: &sentAt, DeletedAt: &sentAt},
+		},
+	}
+	repo.EXPECT().CreateChat(gomock.Any(), ch, user.ID).Return(ch, nil).Times(1)
+
+	srv := NewHiTalentService(repo, nil, nil)
+	chat, err := srv.CreateChat(context.Background(), user, ch)
+	require.NoError(t, err)
+	require.Equal(t, "Welcome", chat.Messages[0].Text)
+	// JWT callers are the sender of every message, as when posting, and
+	// fields storage assigns are dropped.
+	for _, message := range chat.Messages {
+		require.Equal(t, "42", message.SenderID)
+		require.Equal(t, "Alice", message.SenderName)
+		require.Zero(t, message.ID)
+		require.Zero(t, message.ChatID)
+		require.True(t, message.CreatedAt.IsZero())
+		require.Nil(t, message.EditedAt)
+		require.Nil(t, message.DeletedAt)
+	}
+}
+
 func TestHiTalentService_CreateChatFail(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
@@ -69,13 +114,37 @@ func TestHiTalentService_CreateChatFail(t *testing.T) {
 			},
 			expErr: "max",
 		},
+		{
+			name: "empty message",
+			chat: &models.Chat{
+				Title:    "Onboarding",
+				Messages: []*models.Message{{Text: "Welcome"}, {Text: "  "}},
+			},
+			expErr: "Chat.messages[1].text",
+		},
+		{
+			name: "null message",
+			chat: &models.Chat{
+				Title:    "Onboarding",
+				Messages: []*models.Message{nil},
+			},
+			expErr: "required",
+		},
+		{
+			name: "too many messages",
+			chat: &models.Chat{
+				Title:    "Onboarding",
+				Messages: make([]*models.Message, 101),
+			},
+			expErr: "max",
+		},
 	}
 
-	srv := NewHiTalentService(context.Background(), repo)
+	srv := NewHiTalentService(repo, nil, nil)
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			chat, err := srv.CreateChat(tc.chat)
+			chat, err := srv.CreateChat(context.Background(), operator, tc.chat)
 			require.Error(t, err)
 			require.Nil(t, chat)
 			require.Contains(t, err.Error(), tc.expErr)
@@ -102,11 +171,64 @@ func TestHiTalentService_GetChatSuccess(t *testing.T) {
 		},
 	}
 
-	repo.EXPECT().GetChat(1, limit).Return(expResp, nil).Times(1)
-	srv := NewHiTalentService(context.Background(), repo)
-	result, err := srv.GetChat(chatID, limit)
+	query := &models.MessagesQuery{Limit: limit}
+	repo.EXPECT().GetChat(gomock.Any(), 1, query).Return(expResp, nil).Times(1)
+	srv := NewHiTalentService(repo, nil, nil)
+	result, err := srv.GetChat(context.Background(), operator, chatID, query)
 	require.NoError(t, err)
 	require.Equal(t, expResp, result)
+	require.Empty(t, result.NextCursor)
+}
+
+func TestHiTalentService_GetChatNextCursor(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	newest := &models.Message{ID: 3, ChatID: 1, Text: "Message 3", CreatedAt: time.Unix(300, 0).UTC()}
+	oldest := &models.Message{ID: 2, ChatID: 1, Text: "Message 2", CreatedAt: time.Unix(200, 0).UTC()}
+
+	cases := []struct {
+		name      string
+		query     *models.MessagesQuery
+		expCursor *models.MessageCursor
+	}{
+		{
+			name:      "first page continues with oldest message",
+			query:     &models.MessagesQuery{Limit: 2},
+			expCursor: models.NewMessageCursor(oldest),
+		},
+		{
+			name:      "before page continues with oldest message",
+			query:     &models.MessagesQuery{Limit: 2, Before: &models.MessageCursor{CreatedAt: time.Unix(400, 0).UTC(), ID: 4}},
+			expCursor: models.NewMessageCursor(oldest),
+		},
+		{
+			name:      "after page continues with newest message",
+			query:     &models.MessagesQuery{Limit: 2, After: &models.MessageCursor{CreatedAt: time.Unix(100, 0).UTC(), ID: 1}},
+			expCursor: models.NewMessageCursor(newest),
+		},
+	}
+
+	srv := NewHiTalentService(repo, nil, nil)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo.EXPECT().GetChat(gomock.Any(), 1, tc.query).Return(&models.ChatAndMessagesResponse{
+				Chat:     &models.Chat{ID: 1, Title: "Test Chat"},
+				Messages: []*models.Message{newest, oldest},
+				HasMore:  true,
+			}, nil).Times(1)
+
+			result, err := srv.GetChat(context.Background(), operator, "1", tc.query)
+			require.NoError(t, err)
+			require.True(t, result.HasMore)
+
+			cursor, err := models.DecodeMessageCursor(result.NextCursor)
+			require.NoError(t, err)
+			require.Equal(t, tc.expCursor, cursor)
+		})
+	}
 }
 
 func TestHiTalentService_GetChatFail(t *testing.T) {
@@ -119,6 +241,8 @@ func TestHiTalentService_GetChatFail(t *testing.T) {
 		name   string
 		chatID string
 		limit  int
+		before *models.MessageCursor
+		after  *models.MessageCursor
 		expErr string
 	}{
 		{
@@ -151,13 +275,133 @@ func TestHiTalentService_GetChatFail(t *testing.T) {
 			limit:  20,
 			expErr: "invalid chat id",
 		},
+		{
+			name:   "both cursors set",
+			chatID: "1",
+			limit:  20,
+			before: &models.MessageCursor{CreatedAt: time.Now(), ID: 2},
+			after:  &models.MessageCursor{CreatedAt: time.Now(), ID: 1},
+			expErr: "invalid cursor",
+		},
+	}
+
+	srv := NewHiTalentService(repo, nil, nil)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := srv.GetChat(context.Background(), operator, tc.chatID, &models.MessagesQuery{Limit: tc.limit, Before: tc.before, After: tc.after})
+			require.Error(t, err)
+			require.Nil(t, result)
+			require.Contains(t, err.Error(), tc.expErr)
+		})
+	}
+}
+
+func TestHiTalentService_ListChatsSuccess(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	lastActivity := time.Unix(500, 0).UTC()
+	expResp := &models.ChatListResponse{
+		Chats: []*models.Chat{
+			{ID: 2, Title: "Second", CreatedAt: time.Unix(200, 0).UTC(), LastActivityAt: &lastActivity},
+			{ID: 1, Title: "First", CreatedAt: time.Unix(100, 0).UTC(), LastActivityAt: &lastActivity},
+		},
+		HasMore: true,
+	}
+
+	cases := []struct {
+		name      string
+		query     *models.ChatsQuery
+		expQuery  *models.ChatsQuery
+		expCursor *models.ChatCursor
+	}{
+		{
+			name:      "defaults",
+			query:     &models.ChatsQuery{Limit: 2, Title: "  chat "},
+			expQuery:  &models.ChatsQuery{Limit: 2, Title: "chat", Sort: models.ChatSortCreatedAt, Order: models.SortOrderDesc},
+			expCursor: &models.ChatCursor{Sort: models.ChatSortCreatedAt, Order: models.SortOrderDesc, SortValue: time.Unix(100, 0).UTC(), ID: 1},
+		},
+		{
+			name:      "last activity",
+			query:     &models.ChatsQuery{Limit: 2, Sort: models.ChatSortLastActivity, Order: models.SortOrderAsc},
+			expQuery:  &models.ChatsQuery{Limit: 2, Sort: models.ChatSortLastActivity, Order: models.SortOrderAsc},
+			expCursor: &models.ChatCursor{Sort: models.ChatSortLastActivity, Order: models.SortOrderAsc, SortValue: lastActivity, ID: 1},
+		},
+	}
+
+	srv := NewHiTalentService(repo, nil, nil)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo.EXPECT().ListChats(gomock.Any(), tc.expQuery).Return(expResp, nil).Times(1)
+
+			result, err := srv.ListChats(context.Background(), operator, tc.query)
+			require.NoError(t, err)
+			require.Len(t, result.Chats, 2)
+
+			cursor, err := models.DecodeChatCursor(result.NextCursor)
+			require.NoError(t, err)
+			require.Equal(t, tc.expCursor, cursor)
+		})
+	}
+}
+
+func TestHiTalentService_ListChatsFail(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	from := time.Unix(200, 0)
+	to := time.Unix(100, 0)
+
+	cases := []struct {
+		name   string
+		query  *models.ChatsQuery
+		expErr string
+	}{
+		{
+			name:   "nil query",
+			query:  nil,
+			expErr: "query is nil",
+		},
+		{
+			name:   "unknown sort",
+			query:  &models.ChatsQuery{Limit: 20, Sort: "title"},
+			expErr: "invalid sort field",
+		},
+		{
+			name:   "unknown order",
+			query:  &models.ChatsQuery{Limit: 20, Order: "up"},
+			expErr: "invalid sort order",
+		},
+		{
+			name:   "inverted time range",
+			query:  &models.ChatsQuery{Limit: 20, CreatedFrom: &from, CreatedTo: &to},
+			expErr: "invalid time range",
+		},
+		{
+			name: "cursor from another sort",
+			query: &models.ChatsQuery{Limit: 20, Sort: models.ChatSortLastActivity, Cursor: &models.ChatCursor{
+				Sort: models.ChatSortCreatedAt, Order: models.SortOrderDesc, SortValue: time.Unix(100, 0).UTC(), ID: 1,
+			}},
+			expErr: "invalid cursor",
+		},
+		{
+			name: "cursor from another order",
+			query: &models.ChatsQuery{Limit: 20, Order: models.SortOrderAsc, Cursor: &models.ChatCursor{
+				Sort: models.ChatSortCreatedAt, Order: models.SortOrderDesc, SortValue: time.Unix(100, 0).UTC(), ID: 1,
+			}},
+			expErr: "invalid cursor",
+		},
 	}
 
-	srv := NewHiTalentService(context.Background(), repo)
+	srv := NewHiTalentService(repo, nil, nil)
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := srv.GetChat(tc.chatID, tc.limit)
+			result, err := srv.ListChats(context.Background(), operator, tc.query)
 			require.Error(t, err)
 			require.Nil(t, result)
 			require.Contains(t, err.Error(), tc.expErr)
@@ -181,13 +425,80 @@ func TestHiTalentService_CreateMessageSuccess(t *testing.T) {
 		CreatedAt: time.Now(),
 	}
 
-	repo.EXPECT().CreateMessage(1, msg).Return(expResp, nil).Times(1)
-	srv := NewHiTalentService(context.Background(), repo)
-	result, err := srv.CreateMessage(chatID, msg)
+	repo.EXPECT().CreateMessage(gomock.Any(), 1, msg).Return(expResp, nil).Times(1)
+	srv := NewHiTalentService(repo, nil, nil)
+	result, err := srv.CreateMessage(context.Background(), operator, chatID, msg)
 	require.NoError(t, err)
 	require.Equal(t, expResp, result)
 }
 
+func TestHiTalentService_CreateMessageClearsStoredFields(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	at := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	msg := &models.Message{ID: 99, ChatID: 7, Text: "Hi", CreatedAt: at, EditedAt: &at, DeletedAt: &at}
+
+	repo.EXPECT().CreateMessage(gomock.Any(), 1, &models.Message{SenderID: operator.ID, SenderName: operator.Name, Text: "Hi"}).Return(msg, nil).Times(1)
+	srv := NewHiTalentService(repo, nil, nil)
+	_, err := srv.CreateMessage(context.Background(), operator, "1", msg)
+	require.NoError(t, err)
+}
+
+func TestHiTalentService_CreateMessageSender(t *testing.T) {
+	user := &models.Principal{ID: "42", Name: "Alice", Method: models.AuthMethodJWT}
+
+	cases := []struct {
+		name      string
+		principal *models.Principal
+		message   *models.Message
+		expID     string
+		expName   string
+	}{
+		{
+			name:      "user writes as themselves",
+			principal: user,
+			message:   &models.Message{Text: "Hi", SenderID: "7", SenderName: "Bob"},
+			expID:     "42",
+			expName:   "Alice",
+		},
+		{
+			name:      "api key relays explicit sender",
+			principal: operator,
+			message:   &models.Message{Text: "Hi", SenderID: " 7 ", SenderName: "Bob"},
+			expID:     "7",
+			expName:   "Bob",
+		},
+		{
+			name:      "api key without sender",
+			principal: operator,
+			message:   &models.Message{Text: "Hi"},
+			expID:     operator.ID,
+			expName:   operator.Name,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+			repo.EXPECT().GetChatRole(gomock.Any(), 1, "42").Return(models.ChatRoleMember, nil).AnyTimes()
+			repo.EXPECT().CreateMessage(gomock.Any(), 1, gomock.Any()).DoAndReturn(func(_ context.Context, chatId int, message *models.Message) (*models.Message, error) {
+				return message, nil
+			}).Times(1)
+
+			srv := NewHiTalentService(repo, nil, nil)
+			result, err := srv.CreateMessage(context.Background(), tc.principal, "1", tc.message)
+			require.NoError(t, err)
+			require.Equal(t, tc.expID, result.SenderID)
+			require.Equal(t, tc.expName, result.SenderName)
+		})
+	}
+}
+
 func TestHiTalentService_CreateMessageFail(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
@@ -256,11 +567,11 @@ func TestHiTalentService_CreateMessageFail(t *testing.T) {
 		},
 	}
 
-	srv := NewHiTalentService(context.Background(), repo)
+	srv := NewHiTalentService(repo, nil, nil)
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := srv.CreateMessage(tc.chatID, tc.message)
+			result, err := srv.CreateMessage(context.Background(), operator, tc.chatID, tc.message)
 			require.Error(t, err)
 			require.Nil(t, result)
 			require.Contains(t, err.Error(), tc.expErr)
@@ -268,6 +579,114 @@ func TestHiTalentService_CreateMessageFail(t *testing.T) {
 	}
 }
 
+func TestHiTalentService_CreateMessageBatch(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	sentAt := time.Date(2024, 5, 1, 9, 30, 0, 123456789, time.FixedZone("MSK", 3*60*60))
+	messages := []*models.Message{
+		{ID: 99, ChatID: 7, SenderID: "7", SenderName: "Bob", Text: " Hello ", CreatedAt: sentAt, EditedAt: &sentAt, DeletedAt: &sentAt},
+		{Text: "  "},
+		nil,
+		{Text: "From the future", CreatedAt: time.Now().Add(time.Hour)},
+		{Text: "Undated"},
+	}
+
+	repo.EXPECT().CreateMessages(gomock.Any(), 1, []*models.Message{messages[0], messages[4]}).DoAndReturn(
+		func(_ context.Context, chatId int, stored []*models.Message) ([]*models.Message, error) {
+			for i, message := range stored {
+				require.Zero(t, message.ID)
+				require.Zero(t, message.ChatID)
+				message.ID = i + 1
+				message.ChatID = chatId
+			}
+			return stored, nil
+		}).Times(1)
+
+	srv := NewHiTalentService(repo, nil, nil)
+	results, err := srv.CreateMessageBatch(context.Background(), operator, "1", messages)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	for i, result := range results {
+		require.Equal(t, i, result.Index)
+	}
+
+	require.NoError(t, results[0].Err)
+	require.Equal(t, 1, results[0].Message.ID)
+	require.Equal(t, "Hello", results[0].Message.Text)
+	// API keys relay the original sender, as when posting.
+	require.Equal(t, "7", results[0].Message.SenderID)
+	require.Equal(t, time.UTC, results[0].Message.CreatedAt.Location())
+	require.True(t, sentAt.Truncate(time.Microsecond).Equal(results[0].Message.CreatedAt))
+	// Ids and state of the system the messages came from are dropped.
+	require.Nil(t, results[0].Message.EditedAt)
+	require.Nil(t, results[0].Message.DeletedAt)
+
+	require.ErrorContains(t, results[1].Err, "required")
+	require.Nil(t, results[1].Message)
+	require.ErrorIs(t, results[2].Err, suberrors.ErrMessageRequired)
+	require.ErrorIs(t, results[3].Err, suberrors.ErrMessageFromFuture)
+	require.NoError(t, results[4].Err)
+	require.Equal(t, 2, results[4].Message.ID)
+}
+
+func TestHiTalentService_CreateMessageBatchFail(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	member := &models.Principal{ID: "42", Method: models.AuthMethodJWT}
+	repo.EXPECT().GetChatRole(gomock.Any(), 1, "42").Return(models.ChatRoleReadOnly, nil).Times(1)
+
+	cases := []struct {
+		name      string
+		principal *models.Principal
+		chatID    string
+		messages  []*models.Message
+		expErr    error
+	}{
+		{
+			name:      "invalid chat ID",
+			principal: operator,
+			chatID:    "invalid",
+			messages:  []*models.Message{{Text: "Hi"}},
+			expErr:    suberrors.ErrInvalidChatId,
+		},
+		{
+			name:      "empty batch",
+			principal: operator,
+			chatID:    "1",
+			expErr:    suberrors.ErrEmptyMessageBatch,
+		},
+		{
+			name:      "batch too large",
+			principal: operator,
+			chatID:    "1",
+			messages:  make([]*models.Message, models.MaxMessageBatch+1),
+			expErr:    suberrors.ErrMessageBatchTooLarge,
+		},
+		{
+			name:      "read-only member",
+			principal: member,
+			chatID:    "1",
+			messages:  []*models.Message{{Text: "Hi"}},
+			expErr:    suberrors.ErrForbidden,
+		},
+	}
+
+	srv := NewHiTalentService(repo, nil, nil)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := srv.CreateMessageBatch(context.Background(), tc.principal, tc.chatID, tc.messages)
+			require.ErrorIs(t, err, tc.expErr)
+			require.Nil(t, results)
+		})
+	}
+}
+
 func TestHiTalentService_DeleteChatSuccess(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
@@ -275,9 +694,9 @@ func TestHiTalentService_DeleteChatSuccess(t *testing.T) {
 	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
 	chatID := "1"
 
-	repo.EXPECT().DeleteChat(1).Return(nil).Times(1)
-	srv := NewHiTalentService(context.Background(), repo)
-	err := srv.DeleteChat(chatID)
+	repo.EXPECT().DeleteChat(gomock.Any(), 1).Return(nil).Times(1)
+	srv := NewHiTalentService(repo, nil, nil)
+	err := srv.DeleteChat(context.Background(), operator, chatID)
 	require.NoError(t, err)
 }
 
@@ -319,13 +738,835 @@ func TestHiTalentService_DeleteChatFail(t *testing.T) {
 		},
 	}
 
-	srv := NewHiTalentService(context.Background(), repo)
+	srv := NewHiTalentService(repo, nil, nil)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := srv.DeleteChat(context.Background(), operator, tc.chatID)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expErr)
+		})
+	}
+}
+
+func TestHiTalentService_GetChatDeletedMessagePlaceholder(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	deletedAt := time.Now()
+	query := &models.MessagesQuery{Limit: 20}
+
+	repo.EXPECT().GetChat(gomock.Any(), 1, query).Return(&models.ChatAndMessagesResponse{
+		Chat: &models.Chat{ID: 1, Title: "Test Chat"},
+		Messages: []*models.Message{
+			{ID: 2, ChatID: 1, Text: "secret", CreatedAt: time.Now(), DeletedAt: &deletedAt},
+			{ID: 1, ChatID: 1, Text: "Message 1", CreatedAt: time.Now()},
+		},
+	}, nil).Times(1)
+
+	srv := NewHiTalentService(repo, nil, nil)
+	result, err := srv.GetChat(context.Background(), operator, "1", query)
+	require.NoError(t, err)
+	require.Equal(t, models.DeletedMessagePlaceholder, result.Messages[0].Text)
+	require.NotNil(t, result.Messages[0].DeletedAt)
+	require.Equal(t, "Message 1", result.Messages[1].Text)
+}
+
+func TestHiTalentService_UpdateMessageSuccess(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	editedAt := time.Now()
+	expResp := &models.Message{
+		ID:        2,
+		ChatID:    1,
+		Text:      "Edited message",
+		CreatedAt: time.Now(),
+		EditedAt:  &editedAt,
+	}
+
+	repo.EXPECT().UpdateMessage(gomock.Any(), 1, 2, "Edited message").Return(expResp, nil).Times(1)
+	srv := NewHiTalentService(repo, nil, nil)
+	result, err := srv.UpdateMessage(context.Background(), operator, "1", "2", &models.Message{Text: "  Edited message  "})
+	require.NoError(t, err)
+	require.Equal(t, expResp, result)
+}
+
+func TestHiTalentService_UpdateMessageFail(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+
+	cases := []struct {
+		name      string
+		chatID    string
+		messageID string
+		message   *models.Message
+		expErr    string
+	}{
+		{
+			name:      "invalid chat ID",
+			chatID:    "invalid",
+			messageID: "1",
+			message:   &models.Message{Text: "Test message"},
+			expErr:    "invalid chat id",
+		},
+		{
+			name:      "invalid message ID",
+			chatID:    "1",
+			messageID: "abc",
+			message:   &models.Message{Text: "Test message"},
+			expErr:    "invalid message id",
+		},
+		{
+			name:      "zero message ID",
+			chatID:    "1",
+			messageID: "0",
+			message:   &models.Message{Text: "Test message"},
+			expErr:    "message id must be positive",
+		},
+		{
+			name:      "nil message",
+			chatID:    "1",
+			messageID: "1",
+			message:   nil,
+			expErr:    "message is nil",
+		},
+		{
+			name:      "whitespace only text",
+			chatID:    "1",
+			messageID: "1",
+			message:   &models.Message{Text: "   "},
+			expErr:    "required",
+		},
+		{
+			name:      "text too long",
+			chatID:    "1",
+			messageID: "1",
+			message:   &models.Message{Text: strings.Repeat("a", 5001)},
+			expErr:    "max",
+		},
+	}
+
+	srv := NewHiTalentService(repo, nil, nil)
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := srv.DeleteChat(tc.chatID)
+			result, err := srv.UpdateMessage(context.Background(), operator, tc.chatID, tc.messageID, tc.message)
 			require.Error(t, err)
+			require.Nil(t, result)
 			require.Contains(t, err.Error(), tc.expErr)
 		})
 	}
 }
+
+func TestHiTalentService_DeleteMessageSuccess(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+
+	repo.EXPECT().DeleteMessage(gomock.Any(), 1, 2).Return(nil).Times(1)
+	srv := NewHiTalentService(repo, nil, nil)
+	err := srv.DeleteMessage(context.Background(), operator, "1", "2")
+	require.NoError(t, err)
+}
+
+func TestHiTalentService_DeleteMessageFail(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+
+	cases := []struct {
+		name      string
+		chatID    string
+		messageID string
+		expErr    string
+	}{
+		{
+			name:      "negative chat ID",
+			chatID:    "-1",
+			messageID: "1",
+			expErr:    "chat id must be positive",
+		},
+		{
+			name:      "float message ID",
+			chatID:    "1",
+			messageID: "1.5",
+			expErr:    "invalid message id",
+		},
+		{
+			name:      "negative message ID",
+			chatID:    "1",
+			messageID: "-3",
+			expErr:    "message id must be positive",
+		},
+	}
+
+	srv := NewHiTalentService(repo, nil, nil)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := srv.DeleteMessage(context.Background(), operator, tc.chatID, tc.messageID)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expErr)
+		})
+	}
+}
+
+func TestHiTalentService_UpdateChatSuccess(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	expResp := &models.Chat{
+		ID:        1,
+		Title:     "Renamed",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Version:   3,
+	}
+
+	repo.EXPECT().UpdateChat(gomock.Any(), 1, "Renamed", 2).Return(expResp, nil).Times(1)
+	srv := NewHiTalentService(repo, nil, nil)
+	result, err := srv.UpdateChat(context.Background(), operator, "1", &models.Chat{Title: "  Renamed "}, 2)
+	require.NoError(t, err)
+	require.Equal(t, expResp, result)
+}
+
+func TestHiTalentService_UpdateChatFail(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+
+	cases := []struct {
+		name    string
+		chatID  string
+		chat    *models.Chat
+		version int
+		expErr  string
+	}{
+		{
+			name:   "invalid chat ID",
+			chatID: "invalid",
+			chat:   &models.Chat{Title: "Renamed"},
+			expErr: "invalid chat id",
+		},
+		{
+			name:   "zero chat ID",
+			chatID: "0",
+			chat:   &models.Chat{Title: "Renamed"},
+			expErr: "chat id must be positive",
+		},
+		{
+			name:    "negative version",
+			chatID:  "1",
+			chat:    &models.Chat{Title: "Renamed"},
+			version: -1,
+			expErr:  "chat version must be positive",
+		},
+		{
+			name:   "nil chat",
+			chatID: "1",
+			chat:   nil,
+			expErr: "chat is nil",
+		},
+		{
+			name:   "whitespace only title",
+			chatID: "1",
+			chat:   &models.Chat{Title: "   "},
+			expErr: "required",
+		},
+		{
+			name:   "title too long",
+			chatID: "1",
+			chat:   &models.Chat{Title: strings.Repeat("a", 201)},
+			expErr: "max",
+		},
+	}
+
+	srv := NewHiTalentService(repo, nil, nil)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := srv.UpdateChat(context.Background(), operator, tc.chatID, tc.chat, tc.version)
+			require.Error(t, err)
+			require.Nil(t, result)
+			require.Contains(t, err.Error(), tc.expErr)
+		})
+	}
+}
+
+func TestHiTalentService_SubscribeResume(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	broker := events.NewBroker(4)
+	deletedAt := time.Now()
+
+	repo.EXPECT().ChatExists(gomock.Any(), 1).Return(nil).Times(1)
+	repo.EXPECT().ListMessagesAfter(gomock.Any(), 1, 5, replayPageSize).Return([]*models.Message{
+		{ID: 6, ChatID: 1, Text: "Missed", CreatedAt: time.Now()},
+		{ID: 7, ChatID: 1, Text: "secret", CreatedAt: time.Now(), DeletedAt: &deletedAt},
+	}, nil).Times(1)
+
+	srv := NewHiTalentService(repo, broker, nil)
+
+	stream, stop, err := srv.Subscribe(context.Background(), operator, "1", 5)
+	require.NoError(t, err)
+	defer stop()
+
+	// Already replayed from storage, must not be delivered twice.
+	broker.Publish(&models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: &models.Message{ID: 7, ChatID: 1}})
+	broker.Publish(&models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: &models.Message{ID: 8, ChatID: 1, Text: "Live"}})
+
+	event := <-stream
+	require.Equal(t, 6, event.Message.ID)
+	event = <-stream
+	require.Equal(t, 7, event.Message.ID)
+	require.Equal(t, models.DeletedMessagePlaceholder, event.Message.Text)
+	event = <-stream
+	require.Equal(t, 8, event.Message.ID)
+
+	stop()
+	_, ok := <-stream
+	require.False(t, ok)
+}
+
+func TestHiTalentService_SubscribeMember(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	// The role lookup proves the chat exists, so no other query is made.
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	repo.EXPECT().GetChatRole(gomock.Any(), 1, "42").Return(models.ChatRoleReadOnly, nil).Times(1)
+
+	srv := NewHiTalentService(repo, events.NewBroker(4), nil)
+
+	stream, stop, err := srv.Subscribe(context.Background(), &models.Principal{ID: "42", Method: models.AuthMethodJWT}, "1", 0)
+	require.NoError(t, err)
+	require.NotNil(t, stream)
+	stop()
+}
+
+func TestHiTalentService_SubscribeFail(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	repo.EXPECT().ChatExists(gomock.Any(), 404).Return(suberrors.ErrChatNotFound).Times(1)
+	repo.EXPECT().GetChatRole(gomock.Any(), 405, "42").Return("", suberrors.ErrChatNotFound).Times(1)
+
+	member := &models.Principal{ID: "42", Method: models.AuthMethodJWT}
+
+	cases := []struct {
+		name        string
+		principal   *models.Principal
+		chatID      string
+		lastEventID int
+		expErr      string
+	}{
+		{
+			name:   "invalid chat ID",
+			chatID: "invalid",
+			expErr: "invalid chat id",
+		},
+		{
+			name:        "negative last event ID",
+			chatID:      "1",
+			lastEventID: -1,
+			expErr:      "invalid event id",
+		},
+		{
+			name:   "chat not found",
+			chatID: "404",
+			expErr: "chat id not found",
+		},
+		{
+			name:      "chat not found for member",
+			principal: member,
+			chatID:    "405",
+			expErr:    "chat id not found",
+		},
+	}
+
+	srv := NewHiTalentService(repo, events.NewBroker(4), nil)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			principal := tc.principal
+			if principal == nil {
+				principal = operator
+			}
+			stream, stop, err := srv.Subscribe(context.Background(), principal, tc.chatID, tc.lastEventID)
+			require.Error(t, err)
+			require.Nil(t, stream)
+			require.Nil(t, stop)
+			require.Contains(t, err.Error(), tc.expErr)
+		})
+	}
+}
+
+func TestHiTalentService_SearchSuccess(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	expResp := &models.SearchResponse{
+		Results: []*models.SearchResult{
+			{Message: &models.Message{ID: 3, ChatID: 1, Text: "Deploy at noon"}, Rank: 0.6, Snippet: "<mark>Deploy</mark> at noon"},
+			{Message: &models.Message{ID: 9, ChatID: 2, Text: "Deploy failed"}, Rank: 0.3, Snippet: "<mark>Deploy</mark> failed"},
+		},
+		HasMore: true,
+	}
+
+	cases := []struct {
+		name      string
+		chatID    string
+		query     *models.SearchQuery
+		expQuery  *models.SearchQuery
+		expOffset int
+	}{
+		{
+			name:      "all chats",
+			query:     &models.SearchQuery{Query: "  deploy ", Limit: 2},
+			expQuery:  &models.SearchQuery{Query: "deploy", Limit: 2},
+			expOffset: 2,
+		},
+		{
+			name:      "one chat next page",
+			chatID:    "1",
+			query:     &models.SearchQuery{Query: "deploy", Limit: 2, Cursor: &models.SearchCursor{Offset: 2}},
+			expQuery:  &models.SearchQuery{Query: "deploy", ChatID: 1, Limit: 2, Cursor: &models.SearchCursor{Offset: 2}},
+			expOffset: 4,
+		},
+	}
+
+	srv := NewHiTalentService(repo, nil, nil)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo.EXPECT().Search(gomock.Any(), tc.expQuery).Return(expResp, nil).Times(1)
+
+			var result *models.SearchResponse
+			var err error
+			if tc.chatID != "" {
+				result, err = srv.SearchChat(context.Background(), operator, tc.chatID, tc.query)
+			} else {
+				result, err = srv.Search(context.Background(), operator, tc.query)
+			}
+			require.NoError(t, err)
+			require.Len(t, result.Results, 2)
+
+			cursor, err := models.DecodeSearchCursor(result.NextCursor)
+			require.NoError(t, err)
+			require.Equal(t, tc.expOffset, cursor.Offset)
+		})
+	}
+}
+
+func TestHiTalentService_SearchFail(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	repo.EXPECT().Search(gomock.Any(), &models.SearchQuery{Query: "deploy", ChatID: 404, Limit: 20}).Return(nil, suberrors.ErrChatNotFound).Times(1)
+
+	cases := []struct {
+		name   string
+		chatID string
+		query  *models.SearchQuery
+		expErr string
+	}{
+		{
+			name:   "nil query",
+			query:  nil,
+			expErr: "query is nil",
+		},
+		{
+			name:   "blank query",
+			query:  &models.SearchQuery{Query: "   ", Limit: 20},
+			expErr: "search query is required",
+		},
+		{
+			name:   "invalid chat ID",
+			chatID: "invalid",
+			query:  &models.SearchQuery{Query: "deploy", Limit: 20},
+			expErr: "invalid chat id",
+		},
+		{
+			name:   "chat not found",
+			chatID: "404",
+			query:  &models.SearchQuery{Query: "deploy", Limit: 20},
+			expErr: "chat id not found",
+		},
+	}
+
+	srv := NewHiTalentService(repo, nil, nil)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var result *models.SearchResponse
+			var err error
+			if tc.chatID != "" {
+				result, err = srv.SearchChat(context.Background(), operator, tc.chatID, tc.query)
+			} else {
+				result, err = srv.Search(context.Background(), operator, tc.query)
+			}
+			require.Error(t, err)
+			require.Nil(t, result)
+			require.Contains(t, err.Error(), tc.expErr)
+		})
+	}
+}
+
+func TestHiTalentService_Authenticate(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	verifier := mocks.NewMockTokenVerifier(ctl)
+
+	apiKey := auth.APIKeyPrefix + "valid"
+	repo.EXPECT().GetAPIKey(gomock.Any(), auth.HashAPIKey(apiKey)).Return(&models.APIKey{ID: 3, Name: "ci"}, nil).Times(1)
+	repo.EXPECT().GetAPIKey(gomock.Any(), auth.HashAPIKey(auth.APIKeyPrefix+"revoked")).Return(nil, suberrors.ErrAPIKeyNotFound).Times(1)
+
+	jwtPrincipal := &models.Principal{ID: "42", Method: models.AuthMethodJWT}
+	verifier.EXPECT().Verify("good.jwt").Return(jwtPrincipal, nil).Times(1)
+	verifier.EXPECT().Verify("bad.jwt").Return(nil, errors.New("token is expired")).Times(1)
+
+	srv := NewHiTalentService(repo, nil, verifier)
+
+	principal, err := srv.Authenticate(context.Background(), apiKey)
+	require.NoError(t, err)
+	require.Equal(t, &models.Principal{ID: "api_key:3", Name: "ci", Method: models.AuthMethodAPIKey}, principal)
+
+	principal, err = srv.Authenticate(context.Background(), "good.jwt")
+	require.NoError(t, err)
+	require.Equal(t, jwtPrincipal, principal)
+
+	for _, credential := range []string{"", "  ", auth.APIKeyPrefix + "revoked", "bad.jwt"} {
+		principal, err = srv.Authenticate(context.Background(), credential)
+		require.ErrorIs(t, err, suberrors.ErrUnauthorized)
+		require.Nil(t, principal)
+	}
+}
+
+func TestHiTalentService_Authorize(t *testing.T) {
+	user := &models.Principal{ID: "42", Method: models.AuthMethodJWT}
+
+	operations := []struct {
+		name    string
+		allowed []string
+		expect  func(repo *mocks.MockHiTalentRepositoryInterface)
+		call    func(srv *HiTalentService) error
+	}{
+		{
+			name:    "get chat",
+			allowed: []string{models.ChatRoleOwner, models.ChatRoleMember, models.ChatRoleReadOnly},
+			expect: func(repo *mocks.MockHiTalentRepositoryInterface) {
+				repo.EXPECT().GetChat(gomock.Any(), 1, gomock.Any()).Return(&models.ChatAndMessagesResponse{Chat: &models.Chat{ID: 1}}, nil)
+			},
+			call: func(srv *HiTalentService) error {
+				_, err := srv.GetChat(context.Background(), user, "1", &models.MessagesQuery{Limit: 20})
+				return err
+			},
+		},
+		{
+			name:    "create message",
+			allowed: []string{models.ChatRoleOwner, models.ChatRoleMember},
+			expect: func(repo *mocks.MockHiTalentRepositoryInterface) {
+				repo.EXPECT().CreateMessage(gomock.Any(), 1, gomock.Any()).Return(&models.Message{ID: 1, ChatID: 1}, nil)
+			},
+			call: func(srv *HiTalentService) error {
+				_, err := srv.CreateMessage(context.Background(), user, "1", &models.Message{Text: "Hello"})
+				return err
+			},
+		},
+		{
+			name:    "delete chat",
+			allowed: []string{models.ChatRoleOwner},
+			expect: func(repo *mocks.MockHiTalentRepositoryInterface) {
+				repo.EXPECT().DeleteChat(gomock.Any(), 1).Return(nil)
+			},
+			call: func(srv *HiTalentService) error {
+				return srv.DeleteChat(context.Background(), user, "1")
+			},
+		},
+	}
+
+	roles := []string{models.ChatRoleOwner, models.ChatRoleMember, models.ChatRoleReadOnly, ""}
+
+	for _, op := range operations {
+		for _, role := range roles {
+			t.Run(op.name+" as "+role, func(t *testing.T) {
+				ctl := gomock.NewController(t)
+				defer ctl.Finish()
+
+				repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+				repo.EXPECT().GetChatRole(gomock.Any(), 1, "42").Return(role, nil).Times(1)
+
+				allowed := slices.Contains(op.allowed, role)
+				if allowed {
+					op.expect(repo)
+				}
+
+				srv := NewHiTalentService(repo, nil, nil)
+				err := op.call(srv)
+				if allowed {
+					require.NoError(t, err)
+				} else {
+					require.ErrorIs(t, err, suberrors.ErrForbidden)
+				}
+			})
+		}
+	}
+
+	t.Run("chat not found", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+		repo.EXPECT().GetChatRole(gomock.Any(), 404, "42").Return("", suberrors.ErrChatNotFound).Times(1)
+
+		srv := NewHiTalentService(repo, nil, nil)
+		err := srv.DeleteChat(context.Background(), user, "404")
+		require.ErrorIs(t, err, suberrors.ErrChatNotFound)
+	})
+
+	t.Run("no principal", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		srv := NewHiTalentService(mocks.NewMockHiTalentRepositoryInterface(ctl), nil, nil)
+		err := srv.DeleteChat(context.Background(), nil, "1")
+		require.ErrorIs(t, err, suberrors.ErrUnauthorized)
+	})
+}
+
+func TestHiTalentService_ListChatsOnlyMemberChats(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	repo.EXPECT().ListChats(gomock.Any(), &models.ChatsQuery{
+		Limit:    20,
+		Sort:     models.ChatSortCreatedAt,
+		Order:    models.SortOrderDesc,
+		MemberID: "42",
+	}).Return(&models.ChatListResponse{}, nil).Times(1)
+
+	srv := NewHiTalentService(repo, nil, nil)
+	_, err := srv.ListChats(context.Background(), &models.Principal{ID: "42", Method: models.AuthMethodJWT}, &models.ChatsQuery{Limit: 20})
+	require.NoError(t, err)
+}
+
+func TestHiTalentService_AddChatMember(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	owner := &models.Principal{ID: "42", Method: models.AuthMethodJWT}
+
+	expResp := &models.ChatMember{ChatID: 1, UserID: "7", Role: models.ChatRoleReadOnly, CreatedAt: time.Now()}
+	repo.EXPECT().GetChatRole(gomock.Any(), 1, "42").Return(models.ChatRoleOwner, nil).Times(1)
+	repo.EXPECT().GetChatRole(gomock.Any(), 1, "7").Return("", nil).Times(1)
+	repo.EXPECT().AddChatMember(gomock.Any(), &models.ChatMember{ChatID: 1, UserID: "7", Role: models.ChatRoleReadOnly}).Return(expResp, nil).Times(1)
+
+	srv := NewHiTalentService(repo, nil, nil)
+
+	result, err := srv.AddChatMember(context.Background(), owner, "1", &models.ChatMember{UserID: " 7 ", Role: models.ChatRoleReadOnly})
+	require.NoError(t, err)
+	require.Equal(t, expResp, result)
+
+	cases := []struct {
+		name   string
+		chatID string
+		member *models.ChatMember
+		expErr string
+	}{
+		{
+			name:   "invalid chat ID",
+			chatID: "invalid",
+			member: &models.ChatMember{UserID: "7", Role: models.ChatRoleMember},
+			expErr: "invalid chat id",
+		},
+		{
+			name:   "nil member",
+			chatID: "1",
+			expErr: "member is nil",
+		},
+		{
+			name:   "missing user",
+			chatID: "1",
+			member: &models.ChatMember{Role: models.ChatRoleMember},
+			expErr: "user_id",
+		},
+		{
+			name:   "unknown role",
+			chatID: "1",
+			member: &models.ChatMember{UserID: "7", Role: "admin"},
+			expErr: "role",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := srv.AddChatMember(context.Background(), owner, tc.chatID, tc.member)
+			require.Error(t, err)
+			require.Nil(t, result)
+			require.Contains(t, err.Error(), tc.expErr)
+		})
+	}
+}
+
+func TestHiTalentService_RemoveChatMember(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	member := &models.Principal{ID: "7", Method: models.AuthMethodJWT}
+
+	repo.EXPECT().GetChatRole(gomock.Any(), 1, "7").Return(models.ChatRoleMember, nil).Times(3)
+	repo.EXPECT().RemoveChatMember(gomock.Any(), 1, "7").Return(nil).Times(1)
+
+	srv := NewHiTalentService(repo, nil, nil)
+
+	// Members may leave a chat but not remove anybody else.
+	require.NoError(t, srv.RemoveChatMember(context.Background(), member, "1", "7"))
+	require.ErrorIs(t, srv.RemoveChatMember(context.Background(), member, "1", "42"), suberrors.ErrForbidden)
+}
+
+func TestHiTalentService_LastOwner(t *testing.T) {
+	owner := &models.Principal{ID: "42", Method: models.AuthMethodJWT}
+
+	t.Run("last owner cannot leave", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+		repo.EXPECT().GetChatRole(gomock.Any(), 1, "42").Return(models.ChatRoleOwner, nil).Times(2)
+		repo.EXPECT().CountChatOwners(gomock.Any(), 1).Return(1, nil).Times(1)
+
+		srv := NewHiTalentService(repo, nil, nil)
+		require.ErrorIs(t, srv.RemoveChatMember(context.Background(), owner, "1", "42"), suberrors.ErrLastOwner)
+	})
+
+	t.Run("last owner cannot be demoted", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+		repo.EXPECT().GetChatRole(gomock.Any(), 1, "42").Return(models.ChatRoleOwner, nil).Times(2)
+		repo.EXPECT().CountChatOwners(gomock.Any(), 1).Return(1, nil).Times(1)
+
+		srv := NewHiTalentService(repo, nil, nil)
+		_, err := srv.AddChatMember(context.Background(), owner, "1", &models.ChatMember{UserID: "42", Role: models.ChatRoleMember})
+		require.ErrorIs(t, err, suberrors.ErrLastOwner)
+	})
+
+	t.Run("owner leaves when another owner remains", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+		repo.EXPECT().GetChatRole(gomock.Any(), 1, "42").Return(models.ChatRoleOwner, nil).Times(2)
+		repo.EXPECT().CountChatOwners(gomock.Any(), 1).Return(2, nil).Times(1)
+		repo.EXPECT().RemoveChatMember(gomock.Any(), 1, "42").Return(nil).Times(1)
+
+		srv := NewHiTalentService(repo, nil, nil)
+		require.NoError(t, srv.RemoveChatMember(context.Background(), owner, "1", "42"))
+	})
+}
+
+func TestHiTalentService_OnlyAuthorChangesMessage(t *testing.T) {
+	member := &models.Principal{ID: "7", Method: models.AuthMethodJWT}
+	owner := &models.Principal{ID: "42", Method: models.AuthMethodJWT}
+
+	t.Run("member edits own message", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+		repo.EXPECT().GetChatRole(gomock.Any(), 1, "7").Return(models.ChatRoleMember, nil).Times(1)
+		repo.EXPECT().GetMessage(gomock.Any(), 1, 2).Return(&models.Message{ID: 2, ChatID: 1, SenderID: "7"}, nil).Times(1)
+		repo.EXPECT().UpdateMessage(gomock.Any(), 1, 2, "Edited").Return(&models.Message{ID: 2, ChatID: 1, SenderID: "7", Text: "Edited"}, nil).Times(1)
+
+		srv := NewHiTalentService(repo, nil, nil)
+		_, err := srv.UpdateMessage(context.Background(), member, "1", "2", &models.Message{Text: "Edited"})
+		require.NoError(t, err)
+	})
+
+	t.Run("member cannot edit another user's message", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+		repo.EXPECT().GetChatRole(gomock.Any(), 1, "7").Return(models.ChatRoleMember, nil).Times(1)
+		repo.EXPECT().GetMessage(gomock.Any(), 1, 2).Return(&models.Message{ID: 2, ChatID: 1, SenderID: "42"}, nil).Times(1)
+
+		srv := NewHiTalentService(repo, nil, nil)
+		_, err := srv.UpdateMessage(context.Background(), member, "1", "2", &models.Message{Text: "Edited"})
+		require.ErrorIs(t, err, suberrors.ErrForbidden)
+	})
+
+	t.Run("member cannot delete another user's message", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+		repo.EXPECT().GetChatRole(gomock.Any(), 1, "7").Return(models.ChatRoleMember, nil).Times(1)
+		repo.EXPECT().GetMessage(gomock.Any(), 1, 2).Return(&models.Message{ID: 2, ChatID: 1, SenderID: "42"}, nil).Times(1)
+
+		srv := NewHiTalentService(repo, nil, nil)
+		require.ErrorIs(t, srv.DeleteMessage(context.Background(), member, "1", "2"), suberrors.ErrForbidden)
+	})
+
+	t.Run("owner deletes any message", func(t *testing.T) {
+		ctl := gomock.NewController(t)
+		defer ctl.Finish()
+
+		repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+		repo.EXPECT().GetChatRole(gomock.Any(), 1, "42").Return(models.ChatRoleOwner, nil).Times(1)
+		repo.EXPECT().DeleteMessage(gomock.Any(), 1, 2).Return(nil).Times(1)
+
+		srv := NewHiTalentService(repo, nil, nil)
+		require.NoError(t, srv.DeleteMessage(context.Background(), owner, "1", "2"))
+	})
+}
+
+func TestTracedService(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	var repoSpan trace.SpanContext
+	repo := mocks.NewMockHiTalentRepositoryInterface(ctl)
+	repo.EXPECT().GetChat(gomock.Any(), 404, gomock.Any()).DoAndReturn(func(ctx context.Context, _ int, _ *models.MessagesQuery) (*models.ChatAndMessagesResponse, error) {
+		repoSpan = trace.SpanContextFromContext(ctx)
+		return nil, suberrors.ErrChatNotFound
+	}).Times(1)
+
+	srv := NewTracedService(NewHiTalentService(repo, nil, nil), tp)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	_, err := srv.GetChat(ctx, operator, "404", &models.MessagesQuery{Limit: 20})
+	parent.End()
+	require.ErrorIs(t, err, suberrors.ErrChatNotFound)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	require.Equal(t, "HiTalentService.GetChat", span.Name)
+	require.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+	require.Equal(t, codes.Error, span.Status.Code)
+	require.Equal(t, span.SpanContext.SpanID(), repoSpan.SpanID())
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"TestHitalent/internal/models"
+	"TestHitalent/pkg/tracing"
+	"context"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedService records a span around every HiTalentService call, between
+// the HTTP span of the request and the spans of the queries it runs.
+type TracedService struct {
+	next   *HiTalentService
+	tracer trace.Tracer
+}
+
+func NewTracedService(next *HiTalentService, tp trace.TracerProvider) *TracedService {
+	return &TracedService{
+		next:   next,
+		tracer: tracing.Tracer(tp),
+	}
+}
+
+func traced[T any](ctx context.Context, s *TracedService, method string, call func(context.Context) (T, error)) (T, error) {
+	ctx, span := s.tracer.Start(ctx, "HiTalentService."+method)
+	defer span.End()
+
+	v, err := call(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return v, err
+}
+
+func tracedErr(ctx context.Context, s *TracedService, method string, call func(context.Context) error) error {
+	_, err := traced(ctx, s, method, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, call(ctx)
+	})
+	return err
+}
+
+func (s *TracedService) Authenticate(ctx context.Context, credential string) (*models.Principal, error) {
+	return traced(ctx, s, "Authenticate", func(ctx context.Context) (*models.Principal, error) {
+		return s.next.Authenticate(ctx, credential)
+	})
+}
+
+func (s *TracedService) CreateChat(ctx context.Context, principal *models.Principal, chat *models.Chat) (*models.Chat, error) {
+	return traced(ctx, s, "CreateChat", func(ctx context.Context) (*models.Chat, error) {
+		return s.next.CreateChat(ctx, principal, chat)
+	})
+}
+
+func (s *TracedService) CreateMessage(ctx context.Context, principal *models.Principal, chatId string, message *models.Message) (*models.Message, error) {
+	return traced(ctx, s, "CreateMessage", func(ctx context.Context) (*models.Message, error) {
+		return s.next.CreateMessage(ctx, principal, chatId, message)
+	})
+}
+
+func (s *TracedService) CreateMessageBatch(ctx context.Context, principal *models.Principal, chatId string, messages []*models.Message) ([]*models.MessageBatchResult, error) {
+	return traced(ctx, s, "CreateMessageBatch", func(ctx context.Context) ([]*models.MessageBatchResult, error) {
+		return s.next.CreateMessageBatch(ctx, principal, chatId, messages)
+	})
+}
+
+func (s *TracedService) GetChat(ctx context.Context, principal *models.Principal, chatId string, query *models.MessagesQuery) (*models.ChatAndMessagesResponse, error) {
+	return traced(ctx, s, "GetChat", func(ctx context.Context) (*models.ChatAndMessagesResponse, error) {
+		return s.next.GetChat(ctx, principal, chatId, query)
+	})
+}
+
+func (s *TracedService) ListChats(ctx context.Context, principal *models.Principal, query *models.ChatsQuery) (*models.ChatListResponse, error) {
+	return traced(ctx, s, "ListChats", func(ctx context.Context) (*models.ChatListResponse, error) {
+		return s.next.ListChats(ctx, principal, query)
+	})
+}
+
+func (s *TracedService) UpdateChat(ctx context.Context, principal *models.Principal, chatId string, chat *models.Chat, version int) (*models.Chat, error) {
+	return traced(ctx, s, "UpdateChat", func(ctx context.Context) (*models.Chat, error) {
+		return s.next.UpdateChat(ctx, principal, chatId, chat, version)
+	})
+}
+
+func (s *TracedService) DeleteChat(ctx context.Context, principal *models.Principal, chatId string) error {
+	return tracedErr(ctx, s, "DeleteChat", func(ctx context.Context) error {
+		return s.next.DeleteChat(ctx, principal, chatId)
+	})
+}
+
+func (s *TracedService) UpdateMessage(ctx context.Context, principal *models.Principal, chatId string, messageId string, message *models.Message) (*models.Message, error) {
+	return traced(ctx, s, "UpdateMessage", func(ctx context.Context) (*models.Message, error) {
+		return s.next.UpdateMessage(ctx, principal, chatId, messageId, message)
+	})
+}
+
+func (s *TracedService) DeleteMessage(ctx context.Context, principal *models.Principal, chatId string, messageId string) error {
+	return tracedErr(ctx, s, "DeleteMessage", func(ctx context.Context) error {
+		return s.next.DeleteMessage(ctx, principal, chatId, messageId)
+	})
+}
+
+// Subscribe is traced until the stream is set up, not for its whole life.
+func (s *TracedService) Subscribe(ctx context.Context, principal *models.Principal, chatId string, lastEventId int) (<-chan *models.Event, func(), error) {
+	var stop func()
+	events, err := traced(ctx, s, "Subscribe", func(ctx context.Context) (<-chan *models.Event, error) {
+		events, cancel, err := s.next.Subscribe(ctx, principal, chatId, lastEventId)
+		stop = cancel
+		return events, err
+	})
+	return events, stop, err
+}
+
+func (s *TracedService) Search(ctx context.Context, principal *models.Principal, query *models.SearchQuery) (*models.SearchResponse, error) {
+	return traced(ctx, s, "Search", func(ctx context.Context) (*models.SearchResponse, error) {
+		return s.next.Search(ctx, principal, query)
+	})
+}
+
+func (s *TracedService) SearchChat(ctx context.Context, principal *models.Principal, chatId string, query *models.SearchQuery) (*models.SearchResponse, error) {
+	return traced(ctx, s, "SearchChat", func(ctx context.Context) (*models.SearchResponse, error) {
+		return s.next.SearchChat(ctx, principal, chatId, query)
+	})
+}
+
+func (s *TracedService) ListChatMembers(ctx context.Context, principal *models.Principal, chatId string) ([]*models.ChatMember, error) {
+	return traced(ctx, s, "ListChatMembers", func(ctx context.Context) ([]*models.ChatMember, error) {
+		return s.next.ListChatMembers(ctx, principal, chatId)
+	})
+}
+
+func (s *TracedService) AddChatMember(ctx context.Context, principal *models.Principal, chatId string, member *models.ChatMember) (*models.ChatMember, error) {
+	return traced(ctx, s, "AddChatMember", func(ctx context.Context) (*models.ChatMember, error) {
+		return s.next.AddChatMember(ctx, principal, chatId, member)
+	})
+}
+
+func (s *TracedService) RemoveChatMember(ctx context.Context, principal *models.Principal, chatId string, userId string) error {
+	return tracedErr(ctx, s, "RemoveChatMember", func(ctx context.Context) error {
+		return s.next.RemoveChatMember(ctx, principal, chatId, userId)
+	})
+}
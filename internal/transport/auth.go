@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"TestHitalent/internal/auth"
+	"TestHitalent/pkg/suberrors"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// AuthMiddleware rejects requests without valid credentials and puts the
+// authenticated principal into the request context. Credentials are read from
+// the Authorization bearer token, the X-API-Key header, or, for EventSource
+// and WebSocket clients that cannot set headers, the access_token parameter.
+// The parameter is accepted only on those streaming routes, so credentials do
+// not end up in URLs, and from there in proxy logs, anywhere else.
+func AuthMiddleware(s *HiTalentServer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := s.service.Authenticate(r.Context(), credentialFromRequest(r))
+		if err != nil {
+			if errors.Is(err, suberrors.ErrUnauthorized) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				s.writeProblem(w, r, problemUnauthorized, "valid API key or bearer token required")
+				return
+			}
+			s.writeError(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+func credentialFromRequest(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if isStreamingRoute(r) {
+		return r.URL.Query().Get("access_token")
+	}
+	return ""
+}
+
+// isStreamingRoute matches GET /api/v1/chats/{id}/events and /ws. It runs
+// before the mux, so the path is matched by hand.
+func isStreamingRoute(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, "/api/v1/chats/")
+	if !ok {
+		return false
+	}
+	id, endpoint, ok := strings.Cut(rest, "/")
+	return ok && id != "" && (endpoint == "events" || endpoint == "ws")
+}
@@ -0,0 +1,129 @@
+package transport
+
+import (
+	"TestHitalent/internal/auth"
+	"TestHitalent/internal/config"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/service/mocks"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	principal := &models.Principal{ID: "42", Method: models.AuthMethodJWT}
+
+	cases := []struct {
+		name           string
+		header         string
+		value          string
+		path           string
+		query          string
+		credential     string
+		authErr        error
+		expectedStatus int
+	}{
+		{
+			name:           "bearer token",
+			header:         "Authorization",
+			value:          "Bearer good.jwt",
+			credential:     "good.jwt",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "api key header",
+			header:         "X-API-Key",
+			value:          "htk_key",
+			credential:     "htk_key",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "access token parameter for events",
+			path:           "/api/v1/chats/1/events",
+			query:          "?access_token=good.jwt",
+			credential:     "good.jwt",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "access token parameter for websocket",
+			path:           "/api/v1/chats/1/ws",
+			query:          "?access_token=good.jwt",
+			credential:     "good.jwt",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "access token parameter on regular endpoint",
+			query:          "?access_token=good.jwt",
+			credential:     "",
+			authErr:        suberrors.ErrUnauthorized,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing credentials",
+			credential:     "",
+			authErr:        suberrors.ErrUnauthorized,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "basic auth is not supported",
+			header:         "Authorization",
+			value:          "Basic dXNlcjpwYXNz",
+			credential:     "",
+			authErr:        suberrors.ErrUnauthorized,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+			if tc.authErr != nil {
+				srv.EXPECT().Authenticate(gomock.Any(), tc.credential).Return(nil, tc.authErr).Times(1)
+			} else {
+				srv.EXPECT().Authenticate(gomock.Any(), tc.credential).Return(principal, nil).Times(1)
+			}
+			server := NewHiTalentServer(cfg, srv, ctx)
+
+			var got *models.Principal
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = auth.PrincipalFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			path := tc.path
+			if path == "" {
+				path = "/api/v1/chats"
+			}
+			req := httptest.NewRequest("GET", path+tc.query, nil)
+			if tc.header != "" {
+				req.Header.Set(tc.header, tc.value)
+			}
+
+			w := httptest.NewRecorder()
+
+			AuthMiddleware(server, next).ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			if tc.authErr != nil {
+				require.Nil(t, got)
+				require.Contains(t, w.Body.String(), "Unauthorized")
+				require.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			} else {
+				require.Equal(t, principal, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package transport
+
+import (
+	"TestHitalent/internal/auth"
+	"TestHitalent/internal/models"
+	"encoding/json"
+	"net/http"
+)
+
+// messageBatchResult is the outcome of one message of a batch, reported with
+// the status it would have got if posted on its own.
+type messageBatchResult struct {
+	Index   int             `json:"index"`
+	Status  int             `json:"status"`
+	Message *models.Message `json:"message,omitempty"`
+	Error   *Problem        `json:"error,omitempty"`
+}
+
+type messageBatchResponse struct {
+	Results []messageBatchResult `json:"results"`
+	Created int                  `json:"created"`
+	Failed  int                  `json:"failed"`
+}
+
+// CreateMessageBatchHandler imports messages into a chat. The batch succeeds
+// as a whole even when some of its messages are invalid; results tell which.
+// It takes a rate limit token per message, so importing is no faster than
+// posting them one by one.
+func CreateMessageBatchHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+
+		defer r.Body.Close()
+
+		req := new(models.MessageBatchRequest)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeProblem(w, r, problemInvalidBody, err.Error())
+			return
+		}
+		if !s.takeTokens(w, r, len(req.Messages)) {
+			return
+		}
+
+		results, err := s.service.CreateMessageBatch(r.Context(), auth.PrincipalFromContext(r.Context()), id, req.Messages)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		resp := messageBatchResponse{Results: make([]messageBatchResult, 0, len(results))}
+		for _, result := range results {
+			if result.Err != nil {
+				p, known := problemFor(result.Err)
+				if !known {
+					s.writeError(w, r, result.Err)
+					return
+				}
+				resp.Results = append(resp.Results, messageBatchResult{Index: result.Index, Status: p.Status, Error: p})
+				resp.Failed++
+				continue
+			}
+
+			msg := result.Message
+			resp.Results = append(resp.Results, messageBatchResult{
+				Index:   result.Index,
+				Status:  http.StatusCreated,
+				Message: &models.Message{ID: msg.ID, ChatID: msg.ChatID, SenderID: msg.SenderID, SenderName: msg.SenderName, Text: msg.Text, CreatedAt: msg.CreatedAt},
+			})
+			resp.Created++
+		}
+
+		s.writeJSON(w, r, http.StatusOK, resp)
+	}
+}
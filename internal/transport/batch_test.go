@@ -0,0 +1,131 @@
+package transport
+
+import (
+	"TestHitalent/internal/config"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/service/mocks"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateMessageBatchHandler_PartialFailure(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	sentAt := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+
+	srv.EXPECT().CreateMessageBatch(gomock.Any(), nil, "1", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *models.Principal, _ string, messages []*models.Message) ([]*models.MessageBatchResult, error) {
+			require.Len(t, messages, 2)
+			require.True(t, sentAt.Equal(messages[0].CreatedAt))
+			messages[0].ID = 5
+			messages[0].ChatID = 1
+			return []*models.MessageBatchResult{
+				{Index: 0, Message: messages[0]},
+				{Index: 1, Err: suberrors.ErrMessageFromFuture},
+			}, nil
+		}).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	body := `{"messages": [{"text": "Hello", "created_at": "2024-05-01T09:30:00Z"}, {"text": "Later", "created_at": "2999-01-01T00:00:00Z"}]}`
+	req := httptest.NewRequest("POST", "/api/v1/chats/1/messages:batch", strings.NewReader(body))
+	req.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	CreateMessageBatchHandler(server)(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp messageBatchResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, 1, resp.Created)
+	require.Equal(t, 1, resp.Failed)
+	require.Len(t, resp.Results, 2)
+
+	require.Equal(t, http.StatusCreated, resp.Results[0].Status)
+	require.Equal(t, 5, resp.Results[0].Message.ID)
+	require.Nil(t, resp.Results[0].Error)
+
+	require.Equal(t, 1, resp.Results[1].Index)
+	require.Equal(t, http.StatusBadRequest, resp.Results[1].Status)
+	require.Equal(t, "invalid_created_at", resp.Results[1].Error.Code)
+	require.Nil(t, resp.Results[1].Message)
+}
+
+func TestCreateMessageBatchHandler_Fail(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+
+	cases := []struct {
+		name           string
+		requestBody    string
+		serviceErr     error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "invalid JSON",
+			requestBody:    `{"messages": [`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request_body",
+		},
+		{
+			name:           "too large",
+			requestBody:    `{"messages": []}`,
+			serviceErr:     suberrors.ErrMessageBatchTooLarge,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCode:   "message_batch_too_large",
+		},
+		{
+			name:           "missing chat",
+			requestBody:    `{"messages": [{"text": "Hello"}]}`,
+			serviceErr:     suberrors.ErrChatNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "chat_not_found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+			if tc.serviceErr != nil {
+				srv.EXPECT().CreateMessageBatch(gomock.Any(), nil, "1", gomock.Any()).Return(nil, tc.serviceErr).Times(1)
+			}
+			server := NewHiTalentServer(cfg, srv, ctx)
+
+			req := httptest.NewRequest("POST", "/api/v1/chats/1/messages:batch", strings.NewReader(tc.requestBody))
+			req.SetPathValue("id", "1")
+			w := httptest.NewRecorder()
+
+			CreateMessageBatchHandler(server)(w, req)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+
+			var problem Problem
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+			require.Equal(t, tc.expectedCode, problem.Code)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package transport
+
+import (
+	"TestHitalent/pkg/suberrors"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body. Code is stable and meant for
+// clients to switch on; Title and Detail are for humans and may change.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Code     string       `json:"code"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes one field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type problemKind struct {
+	status int
+	code   string
+	title  string
+}
+
+var (
+	problemInvalidBody          = problemKind{http.StatusBadRequest, "invalid_request_body", "Invalid request body"}
+	problemInvalidParameter     = problemKind{http.StatusBadRequest, "invalid_parameter", "Invalid query parameter"}
+	problemInvalidHeader        = problemKind{http.StatusBadRequest, "invalid_header", "Invalid request header"}
+	problemUnauthorized         = problemKind{http.StatusUnauthorized, "unauthorized", "Unauthorized"}
+	problemPreconditionRequired = problemKind{http.StatusPreconditionRequired, "if_match_required", "If-Match header is required"}
+	problemValidation           = problemKind{http.StatusBadRequest, "validation_failed", "Validation failed"}
+	problemIdempotencyKeyInUse  = problemKind{http.StatusConflict, "idempotency_key_in_use", "Request with this Idempotency-Key is in progress"}
+	problemIdempotencyKeyReused = problemKind{http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key reused for a different request"}
+	problemRateLimited          = problemKind{http.StatusTooManyRequests, "rate_limited", "Too many requests"}
+	problemInternal             = problemKind{http.StatusInternalServerError, "internal_error", "Internal server error"}
+)
+
+// errorProblems maps service errors to the problem reported for them. Errors
+// not listed here are internal and their text never reaches the client.
+var errorProblems = []struct {
+	err  error
+	kind problemKind
+}{
+	{suberrors.ErrInvalidChatId, problemKind{http.StatusBadRequest, "invalid_chat_id", "Invalid chat id"}},
+	{suberrors.ErrNotPositiveChatId, problemKind{http.StatusBadRequest, "invalid_chat_id", "Invalid chat id"}},
+	{suberrors.ErrInvalidMessageId, problemKind{http.StatusBadRequest, "invalid_message_id", "Invalid message id"}},
+	{suberrors.ErrNotPositiveMessageId, problemKind{http.StatusBadRequest, "invalid_message_id", "Invalid message id"}},
+	{suberrors.ErrInvalidChatVersion, problemKind{http.StatusBadRequest, "invalid_chat_version", "Invalid chat version"}},
+	{suberrors.ErrInvalidCursor, problemKind{http.StatusBadRequest, "invalid_cursor", "Invalid cursor"}},
+	{suberrors.ErrInvalidEventId, problemKind{http.StatusBadRequest, "invalid_event_id", "Invalid event id"}},
+	{suberrors.ErrInvalidSort, problemKind{http.StatusBadRequest, "invalid_sort", "Invalid sort field"}},
+	{suberrors.ErrInvalidSortOrder, problemKind{http.StatusBadRequest, "invalid_sort_order", "Invalid sort order"}},
+	{suberrors.ErrInvalidTimeRange, problemKind{http.StatusBadRequest, "invalid_time_range", "Invalid time range"}},
+	{suberrors.ErrEmptySearchQuery, problemKind{http.StatusBadRequest, "empty_search_query", "Search query is required"}},
+	{suberrors.ErrEmptyMessageBatch, problemKind{http.StatusBadRequest, "empty_message_batch", "Message batch is empty"}},
+	{suberrors.ErrMessageBatchTooLarge, problemKind{http.StatusRequestEntityTooLarge, "message_batch_too_large", "Message batch is too large"}},
+	{suberrors.ErrMessageRequired, problemKind{http.StatusBadRequest, "message_required", "Message is required"}},
+	{suberrors.ErrMessageFromFuture, problemKind{http.StatusBadRequest, "invalid_created_at", "Message time is in the future"}},
+	{suberrors.ErrUnauthorized, problemUnauthorized},
+	{suberrors.ErrAPIKeyNotFound, problemUnauthorized},
+	{suberrors.ErrForbidden, problemKind{http.StatusForbidden, "forbidden", "Forbidden"}},
+	{suberrors.ErrChatNotFound, problemKind{http.StatusNotFound, "chat_not_found", "Chat not found"}},
+	{suberrors.ErrMessageNotFound, problemKind{http.StatusNotFound, "message_not_found", "Message not found"}},
+	{suberrors.ErrMemberNotFound, problemKind{http.StatusNotFound, "member_not_found", "Member not found"}},
+	{suberrors.ErrLastOwner, problemKind{http.StatusConflict, "last_owner", "Chat must keep an owner"}},
+	{suberrors.ErrChatVersionMismatch, problemKind{http.StatusPreconditionFailed, "chat_version_mismatch", "Chat was modified"}},
+}
+
+// problemFor builds the problem reported for err. The second result is false
+// for internal errors, which callers should log.
+func problemFor(err error) (*Problem, bool) {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		p := newProblem(problemValidation, "request has invalid fields")
+		for _, fe := range validationErrs {
+			p.Errors = append(p.Errors, FieldError{
+				Field:   fieldPath(fe),
+				Rule:    fe.Tag(),
+				Message: fieldErrorMessage(fe),
+			})
+		}
+		return p, true
+	}
+
+	for _, e := range errorProblems {
+		if errors.Is(err, e.err) {
+			return newProblem(e.kind, e.err.Error()), true
+		}
+	}
+
+	return newProblem(problemInternal, ""), false
+}
+
+func newProblem(kind problemKind, detail string) *Problem {
+	return &Problem{
+		Type:   "urn:hitalent:problem:" + kind.code,
+		Title:  kind.title,
+		Status: kind.status,
+		Code:   kind.code,
+		Detail: detail,
+	}
+}
+
+// fieldPath names the field from the request body root, such as
+// "messages[1].text" for a field of a nested message.
+func fieldPath(fe validator.FieldError) string {
+	_, path, ok := strings.Cut(fe.Namespace(), ".")
+	if !ok {
+		return fe.Field()
+	}
+	return path
+}
+
+func fieldErrorMessage(fe validator.FieldError) string {
+	if fe.Kind() == reflect.Slice && fe.Tag() == "max" {
+		return "must have at most " + fe.Param() + " items"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + fe.Param() + " characters long"
+	case "max":
+		return "must be at most " + fe.Param() + " characters long"
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	default:
+		return "failed the " + fe.Tag() + " check"
+	}
+}
+
+// writeError reports err as a problem. Internal errors are logged and
+// answered with a generic 500.
+func (s *HiTalentServer) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	p, known := problemFor(err)
+	if !known {
+		s.log(r).Error("request failed",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Error(err),
+		)
+	}
+	writeProblemBody(w, r, p)
+}
+
+// writeProblem reports a request the transport layer rejected itself, such as
+// a malformed body or query parameter.
+func (s *HiTalentServer) writeProblem(w http.ResponseWriter, r *http.Request, kind problemKind, detail string) {
+	writeProblemBody(w, r, newProblem(kind, detail))
+}
+
+func writeProblemBody(w http.ResponseWriter, r *http.Request, p *Problem) {
+	p.Instance = r.URL.Path
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// writeJSON writes a successful response. Once the status is sent an encoding
+// failure can only be logged.
+func (s *HiTalentServer) writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.log(r).Warn("failed to write response", zap.Error(err))
+	}
+}
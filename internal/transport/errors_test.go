@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"TestHitalent/internal/config"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/service/mocks"
+	"TestHitalent/pkg/logger"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWriteError(t *testing.T) {
+	ctx, err := logger.New(context.Background())
+	require.NoError(t, err)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+
+	validationErr := validator.New().Struct(&models.Chat{})
+	require.Error(t, validationErr)
+	nestedValidationErr := validator.New().Struct(&models.Chat{Title: "Onboarding", Messages: []*models.Message{{Text: "Welcome"}, {}}})
+	require.Error(t, nestedValidationErr)
+
+	cases := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+		expectedCode   string
+		expectedDetail string
+		expectedFields []string
+	}{
+		{
+			name:           "invalid chat id",
+			serviceErr:     suberrors.ErrInvalidChatId,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_chat_id",
+			expectedDetail: suberrors.ErrInvalidChatId.Error(),
+		},
+		{
+			name:           "wrapped not found",
+			serviceErr:     errors.Join(errors.New("lookup"), suberrors.ErrChatNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "chat_not_found",
+			expectedDetail: suberrors.ErrChatNotFound.Error(),
+		},
+		{
+			name:           "validation",
+			serviceErr:     validationErr,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "validation_failed",
+			expectedDetail: "request has invalid fields",
+			expectedFields: []string{"Title"},
+		},
+		{
+			name:           "nested validation",
+			serviceErr:     nestedValidationErr,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "validation_failed",
+			expectedDetail: "request has invalid fields",
+			expectedFields: []string{"Messages[1].Text"},
+		},
+		{
+			name:           "internal error is not leaked",
+			serviceErr:     errors.New(`pq: relation "chats" does not exist`),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "internal_error",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+			srv.EXPECT().DeleteChat(gomock.Any(), nil, "1").Return(tc.serviceErr).Times(1)
+			server := NewHiTalentServer(cfg, srv, ctx)
+
+			req := httptest.NewRequest("DELETE", "/api/v1/chats/1", nil)
+			req.SetPathValue("id", "1")
+
+			w := httptest.NewRecorder()
+
+			DeleteChatHandler(server)(w, req)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			require.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+
+			var problem Problem
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+			require.Equal(t, tc.expectedStatus, problem.Status)
+			require.Equal(t, tc.expectedCode, problem.Code)
+			require.Equal(t, tc.expectedDetail, problem.Detail)
+			require.Equal(t, "/api/v1/chats/1", problem.Instance)
+
+			var fields []string
+			for _, fe := range problem.Errors {
+				fields = append(fields, fe.Field)
+			}
+			require.Equal(t, tc.expectedFields, fields)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package transport
+
+import (
+	"TestHitalent/internal/auth"
+	"TestHitalent/internal/models"
+	"TestHitalent/pkg/suberrors"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// sseKeepAliveInterval keeps idle streams from being cut by proxies.
+const sseKeepAliveInterval = 15 * time.Second
+
+// ChatEventsHandler streams chat events as Server-Sent Events. Message events
+// carry the message id as the event id, so a reconnecting EventSource resumes
+// through the Last-Event-ID header (or the last_event_id query parameter).
+func ChatEventsHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+
+		lastEventId, err := parseLastEventId(r)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			s.writeError(w, r, errors.New("streaming is not supported"))
+			return
+		}
+
+		defer r.Body.Close()
+		events, stop, err := s.service.Subscribe(r.Context(), auth.PrincipalFromContext(r.Context()), id, lastEventId)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		defer stop()
+
+		liftDeadlines(w)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(sseKeepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-s.draining:
+				// EventSource reconnects on its own and resumes from the
+				// last event id, possibly on another instance.
+				return
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := writeSSE(w, event); err != nil {
+					s.log(r).Warn("failed to write event", zap.Error(err))
+					return
+				}
+				flusher.Flush()
+				if event.Type == models.EventChatDeleted {
+					return
+				}
+			}
+		}
+	}
+}
+
+// liftDeadlines removes the server read and write timeouts from a stream
+// that is meant to stay open. Writers that do not support deadlines, such as
+// test recorders, have none to lift.
+func liftDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+}
+
+// parseLastEventId reads the resume point from the Last-Event-ID header or the
+// last_event_id query parameter. Zero means the stream starts with live events.
+func parseLastEventId(r *http.Request) (int, error) {
+	lastEventIdStr := r.Header.Get("Last-Event-ID")
+	if lastEventIdStr == "" {
+		lastEventIdStr = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventIdStr == "" {
+		return 0, nil
+	}
+
+	lastEventId, err := strconv.Atoi(lastEventIdStr)
+	if err != nil || lastEventId < 0 {
+		return 0, suberrors.ErrInvalidEventId
+	}
+
+	return lastEventId, nil
+}
+
+func writeSSE(w http.ResponseWriter, event *models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if event.Message != nil {
+		if _, err = fmt.Fprintf(w, "id: %d\n", event.Message.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"TestHitalent/internal/config"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/service/mocks"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestChatEventsHandler_Success(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+
+	stream := make(chan *models.Event, 2)
+	stream <- &models.Event{
+		Type:    models.EventMessageCreated,
+		ChatID:  1,
+		Message: &models.Message{ID: 6, ChatID: 1, Text: "Hello", CreatedAt: time.Now()},
+	}
+	stream <- &models.Event{Type: models.EventChatDeleted, ChatID: 1}
+
+	stopped := false
+	srv.EXPECT().Subscribe(gomock.Any(), nil, "1", 5).Return(stream, func() { stopped = true }, nil).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	req := httptest.NewRequest("GET", "/api/v1/chats/1/events", nil)
+	req.Header.Set("Last-Event-ID", "5")
+	req.SetPathValue("id", "1")
+
+	w := httptest.NewRecorder()
+
+	ChatEventsHandler(server)(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	require.Contains(t, w.Body.String(), "id: 6\nevent: message.created\ndata: {")
+	require.Contains(t, w.Body.String(), "event: chat.deleted\ndata: {")
+	require.True(t, stopped)
+}
+
+func TestChatEventsHandler_Fail(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+
+	cases := []struct {
+		name           string
+		lastEventID    string
+		serviceErr     error
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "invalid Last-Event-ID",
+			lastEventID:    "abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_event_id",
+		},
+		{
+			name:           "chat not found",
+			serviceErr:     suberrors.ErrChatNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Chat not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+			if tc.serviceErr != nil {
+				srv.EXPECT().Subscribe(gomock.Any(), nil, "1", 0).Return(nil, nil, tc.serviceErr).Times(1)
+			}
+			server := NewHiTalentServer(cfg, srv, ctx)
+
+			req := httptest.NewRequest("GET", "/api/v1/chats/1/events", nil)
+			if tc.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tc.lastEventID)
+			}
+			req.SetPathValue("id", "1")
+
+			w := httptest.NewRecorder()
+
+			ChatEventsHandler(server)(w, req)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			require.Contains(t, w.Body.String(), tc.expectedError)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// readinessCheckTimeout bounds all readiness checks of one probe, so a hung
+// dependency fails the probe instead of stalling it.
+const readinessCheckTimeout = 2 * time.Second
+
+// HealthCheck is a dependency /readyz verifies before reporting the instance
+// ready for traffic.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthResponse is the body of /healthz and /readyz.
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+const (
+	healthStatusOK           = "ok"
+	healthStatusUnavailable  = "unavailable"
+	healthStatusShuttingDown = "shutting_down"
+)
+
+// WithReadinessChecks makes /readyz fail while any of checks fails.
+func WithReadinessChecks(checks ...HealthCheck) Option {
+	return func(s *HiTalentServer) {
+		s.readinessChecks = append(s.readinessChecks, checks...)
+	}
+}
+
+// LivenessHandler reports that the process is up and serving HTTP. It checks
+// no dependencies: restarting the process would not fix them.
+func LivenessHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, r, http.StatusOK, HealthResponse{Status: healthStatusOK})
+	}
+}
+
+// ReadinessHandler reports whether the instance should receive traffic. It
+// fails as soon as shutdown starts, so load balancers stop routing to an
+// instance that is draining.
+func ReadinessHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-s.draining:
+			s.writeJSON(w, r, http.StatusServiceUnavailable, HealthResponse{Status: healthStatusShuttingDown})
+			return
+		default:
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), readinessCheckTimeout)
+		defer cancel()
+
+		resp := HealthResponse{Status: healthStatusOK}
+		status := http.StatusOK
+		if len(s.readinessChecks) > 0 {
+			resp.Checks = make(map[string]string, len(s.readinessChecks))
+		}
+		for _, c := range s.readinessChecks {
+			if err := c.Check(ctx); err != nil {
+				resp.Checks[c.Name] = err.Error()
+				resp.Status = healthStatusUnavailable
+				status = http.StatusServiceUnavailable
+				continue
+			}
+			resp.Checks[c.Name] = healthStatusOK
+		}
+
+		s.writeJSON(w, r, status, resp)
+	}
+}
@@ -0,0 +1,137 @@
+package transport
+
+import (
+	"TestHitalent/internal/config"
+	"TestHitalent/internal/service/mocks"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_Health(t *testing.T) {
+	ok := HealthCheck{Name: "database", Check: func(ctx context.Context) error { return nil }}
+	failing := HealthCheck{Name: "migrations", Check: func(ctx context.Context) error {
+		return errors.New("schema at version 7, want 8")
+	}}
+
+	tests := []struct {
+		name       string
+		path       string
+		checks     []HealthCheck
+		wantStatus int
+		wantBody   HealthResponse
+	}{
+		{
+			name:       "liveness ignores failing checks",
+			path:       "/healthz",
+			checks:     []HealthCheck{failing},
+			wantStatus: http.StatusOK,
+			wantBody:   HealthResponse{Status: "ok"},
+		},
+		{
+			name:       "ready without checks",
+			path:       "/readyz",
+			wantStatus: http.StatusOK,
+			wantBody:   HealthResponse{Status: "ok"},
+		},
+		{
+			name:       "ready",
+			path:       "/readyz",
+			checks:     []HealthCheck{ok},
+			wantStatus: http.StatusOK,
+			wantBody:   HealthResponse{Status: "ok", Checks: map[string]string{"database": "ok"}},
+		},
+		{
+			name:       "not ready",
+			path:       "/readyz",
+			checks:     []HealthCheck{ok, failing},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody: HealthResponse{Status: "unavailable", Checks: map[string]string{
+				"database":   "ok",
+				"migrations": "schema at version 7, want 8",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			// Probes carry no credentials, so Authenticate is never called.
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+			cfg := &config.Config{
+				Host: "localhost",
+				Port: "4047",
+			}
+			handler := NewHiTalentServer(cfg, srv, context.Background(), WithReadinessChecks(tt.checks...)).Handler()
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			require.Equal(t, tt.wantStatus, w.Code)
+
+			var body HealthResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			require.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func TestHiTalentServer_ShutdownFailsReadiness(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	cfg := &config.Config{
+		Host:          "127.0.0.1",
+		Port:          "0",
+		ShutdownDelay: 300 * time.Millisecond,
+	}
+	server := NewHiTalentServer(cfg, mocks.NewMockHiTalentServiceInterface(ctl), context.Background())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(l)
+	}()
+	url := "http://" + l.Addr().String() + "/readyz"
+
+	// Idle keep-alive connections would hold Shutdown until its deadline.
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- server.Shutdown(ctx)
+	}()
+
+	// During the delay the listener stays open and reports the drain.
+	require.Eventually(t, func() bool {
+		resp, err := client.Get(url)
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		var body HealthResponse
+		return resp.StatusCode == http.StatusServiceUnavailable &&
+			json.NewDecoder(resp.Body).Decode(&body) == nil &&
+			body.Status == "shutting_down"
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, <-shutdown)
+	require.NoError(t, <-served)
+}
@@ -0,0 +1,178 @@
+package transport
+
+import (
+	"TestHitalent/internal/auth"
+	"TestHitalent/internal/idempotency"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response replayed from the store.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers stored with an idempotent response.
+// Per-request headers such as X-Request-ID or X-RateLimit-* are not replayed.
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// WithIdempotency lets clients retry create requests with an Idempotency-Key
+// header without creating duplicates.
+func WithIdempotency(store idempotency.Store) Option {
+	return func(s *HiTalentServer) {
+		s.idempotency = store
+	}
+}
+
+// idempotent replays the stored response when a request repeats the
+// Idempotency-Key of an earlier one. Keys are scoped to the principal, and a
+// key reused for a different request is rejected with 422. Responses the
+// client should retry for real, 429 and 5xx, free the key instead of being
+// stored.
+func (s *HiTalentServer) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	if s.idempotency == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if !validIdempotencyKey(key) {
+			s.writeProblem(w, r, problemInvalidHeader, "Idempotency-Key must be 1 to 255 printable ASCII characters")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.writeProblem(w, r, problemInvalidBody, err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		scoped := principalKey(auth.PrincipalFromContext(r.Context())) + "|" + key
+		hash := requestHash(r, body)
+		rec, token, err := s.idempotency.Begin(r.Context(), scoped, hash)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		if token == "" {
+			s.replay(w, r, rec, hash)
+			return
+		}
+
+		capture := &captureWriter{ResponseWriter: w}
+		completed := false
+		defer func() {
+			if !completed {
+				// Detached from the request, which may be why we got here.
+				if err := s.idempotency.Release(context.WithoutCancel(r.Context()), scoped, token); err != nil {
+					s.log(r).Error("failed to release idempotency key", zap.Error(err))
+				}
+			}
+		}()
+
+		next(capture, r)
+
+		status := capture.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			return
+		}
+		resp := &idempotency.Response{Status: status, Header: http.Header{}, Body: capture.body.Bytes()}
+		for _, h := range replayedHeaders {
+			for _, v := range w.Header().Values(h) {
+				resp.Header.Add(h, v)
+			}
+		}
+		if err := s.idempotency.Complete(context.WithoutCancel(r.Context()), scoped, token, resp); err != nil {
+			s.log(r).Error("failed to store idempotent response", zap.Error(err))
+			return
+		}
+		completed = true
+	}
+}
+
+func (s *HiTalentServer) replay(w http.ResponseWriter, r *http.Request, rec *idempotency.Record, hash string) {
+	switch {
+	case rec.RequestHash != hash:
+		s.writeProblem(w, r, problemIdempotencyKeyReused, "Idempotency-Key was already used for a different request")
+	case rec.Response == nil:
+		s.writeProblem(w, r, problemIdempotencyKeyInUse, "a request with this Idempotency-Key is still being processed")
+	default:
+		for h, values := range rec.Response.Header {
+			for _, v := range values {
+				w.Header().Add(h, v)
+			}
+		}
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(rec.Response.Status)
+		if _, err := w.Write(rec.Response.Body); err != nil {
+			s.log(r).Warn("failed to write response", zap.Error(err))
+		}
+	}
+}
+
+// requestHash identifies what a request asks for, so a key reused for
+// another request can be told apart from a retry.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func validIdempotencyKey(key string) bool {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// captureWriter keeps a copy of the response body as it is written.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,144 @@
+package transport
+
+import (
+	"TestHitalent/internal/config"
+	"TestHitalent/internal/idempotency"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/service/mocks"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newIdempotencyTestHandler(srv *mocks.MockHiTalentServiceInterface, store idempotency.Store) http.Handler {
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	return NewHiTalentServer(cfg, srv, context.Background(), WithIdempotency(store)).Handler()
+}
+
+func postIdempotent(handler http.Handler, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("X-API-Key", "htk_key")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_IdempotentReplay(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	created := time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC)
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(3)
+	srv.EXPECT().CreateChat(gomock.Any(), apiKeyPrincipal, gomock.Any()).Return(&models.Chat{ID: 1, Title: "Chat", CreatedAt: created, UpdatedAt: created, Version: 1}, nil).Times(1)
+
+	handler := newIdempotencyTestHandler(srv, idempotency.NewMemory(time.Hour))
+
+	first := postIdempotent(handler, "/api/v1/chats", "retry-1", `{"title":"Chat"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	replay := postIdempotent(handler, "/api/v1/chats", "retry-1", `{"title":"Chat"}`)
+	require.Equal(t, http.StatusCreated, replay.Code)
+	require.Equal(t, "true", replay.Header().Get(IdempotentReplayedHeader))
+	require.Equal(t, first.Header().Get("ETag"), replay.Header().Get("ETag"))
+	require.Equal(t, first.Header().Get("Content-Type"), replay.Header().Get("Content-Type"))
+	require.Equal(t, first.Body.String(), replay.Body.String())
+
+	reused := postIdempotent(handler, "/api/v1/chats", "retry-1", `{"title":"Another chat"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	var p Problem
+	require.NoError(t, json.Unmarshal(reused.Body.Bytes(), &p))
+	require.Equal(t, "idempotency_key_reused", p.Code)
+}
+
+func TestHandler_IdempotencyKeyScopedToPrincipal(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	other := &models.Principal{ID: "api_key:2", Name: "mobile", Method: models.AuthMethodAPIKey}
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(1)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_other").Return(other, nil).Times(1)
+	srv.EXPECT().CreateMessage(gomock.Any(), gomock.Any(), "1", gomock.Any()).Return(&models.Message{ID: 1, ChatID: 1, Text: "Hello"}, nil).Times(2)
+
+	handler := newIdempotencyTestHandler(srv, idempotency.NewMemory(time.Hour))
+
+	w := postIdempotent(handler, "/api/v1/chats/1/messages", "same", `{"text":"Hello"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	req := httptest.NewRequest("POST", "/api/v1/chats/1/messages", strings.NewReader(`{"text":"Hello"}`))
+	req.Header.Set("X-API-Key", "htk_other")
+	req.Header.Set(IdempotencyKeyHeader, "same")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+}
+
+func TestHandler_IdempotencyServerErrorFreesKey(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(2)
+	gomock.InOrder(
+		srv.EXPECT().CreateMessage(gomock.Any(), apiKeyPrincipal, "1", gomock.Any()).Return(nil, errors.New("connection reset")),
+		srv.EXPECT().CreateMessage(gomock.Any(), apiKeyPrincipal, "1", gomock.Any()).Return(&models.Message{ID: 1, ChatID: 1, Text: "Hello"}, nil),
+	)
+
+	handler := newIdempotencyTestHandler(srv, idempotency.NewMemory(time.Hour))
+
+	w := postIdempotent(handler, "/api/v1/chats/1/messages", "retry-2", `{"text":"Hello"}`)
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	w = postIdempotent(handler, "/api/v1/chats/1/messages", "retry-2", `{"text":"Hello"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+}
+
+func TestHandler_IdempotencyKeyInUse(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(1)
+
+	store := idempotency.NewMemory(time.Hour)
+	body := `{"text":"Hello"}`
+	req := httptest.NewRequest("POST", "/api/v1/chats/1/messages", nil)
+	_, token, err := store.Begin(context.Background(), principalKey(apiKeyPrincipal)+"|busy", requestHash(req, []byte(body)))
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	w := postIdempotent(newIdempotencyTestHandler(srv, store), "/api/v1/chats/1/messages", "busy", body)
+	require.Equal(t, http.StatusConflict, w.Code)
+	var p Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	require.Equal(t, "idempotency_key_in_use", p.Code)
+}
+
+func TestHandler_InvalidIdempotencyKey(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(1)
+
+	w := postIdempotent(newIdempotencyTestHandler(srv, idempotency.NewMemory(time.Hour)), "/api/v1/chats", strings.Repeat("k", 256), `{"title":"Chat"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
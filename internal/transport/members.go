@@ -0,0 +1,60 @@
+package transport
+
+import (
+	"TestHitalent/internal/auth"
+	"TestHitalent/internal/models"
+	"encoding/json"
+	"net/http"
+)
+
+func ListChatMembersHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+
+		defer r.Body.Close()
+		members, err := s.service.ListChatMembers(r.Context(), auth.PrincipalFromContext(r.Context()), id)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		s.writeJSON(w, r, http.StatusOK, map[string][]*models.ChatMember{"members": members})
+	}
+}
+
+func AddChatMemberHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+
+		defer r.Body.Close()
+
+		req := new(models.ChatMember)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeProblem(w, r, problemInvalidBody, err.Error())
+			return
+		}
+
+		member, err := s.service.AddChatMember(r.Context(), auth.PrincipalFromContext(r.Context()), id, req)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		s.writeJSON(w, r, http.StatusCreated, member)
+	}
+}
+
+func RemoveChatMemberHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		userId := r.PathValue("userId")
+
+		defer r.Body.Close()
+		err := s.service.RemoveChatMember(r.Context(), auth.PrincipalFromContext(r.Context()), id, userId)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,161 @@
+package transport
+
+import (
+	"TestHitalent/internal/auth"
+	"TestHitalent/internal/config"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/service/mocks"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestChatMembersHandlers_Success(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	principal := &models.Principal{ID: "42", Method: models.AuthMethodJWT}
+
+	member := &models.ChatMember{ChatID: 1, UserID: "7", Role: models.ChatRoleMember, CreatedAt: time.Now()}
+	srv.EXPECT().AddChatMember(gomock.Any(), principal, "1", &models.ChatMember{UserID: "7", Role: models.ChatRoleMember}).Return(member, nil).Times(1)
+	srv.EXPECT().ListChatMembers(gomock.Any(), principal, "1").Return([]*models.ChatMember{member}, nil).Times(1)
+	srv.EXPECT().RemoveChatMember(gomock.Any(), principal, "1", "7").Return(nil).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	req := httptest.NewRequest("POST", "/api/v1/chats/1/members", strings.NewReader(`{"user_id":"7","role":"member"}`))
+	req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	req.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	AddChatMemberHandler(server)(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	var added models.ChatMember
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&added))
+	require.Equal(t, "7", added.UserID)
+
+	req = httptest.NewRequest("GET", "/api/v1/chats/1/members", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	req.SetPathValue("id", "1")
+	w = httptest.NewRecorder()
+
+	ListChatMembersHandler(server)(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Members []*models.ChatMember `json:"members"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&listed))
+	require.Len(t, listed.Members, 1)
+
+	req = httptest.NewRequest("DELETE", "/api/v1/chats/1/members/7", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	req.SetPathValue("id", "1")
+	req.SetPathValue("userId", "7")
+	w = httptest.NewRecorder()
+
+	RemoveChatMemberHandler(server)(w, req)
+
+	require.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestAddChatMemberHandler_Fail(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+
+	cases := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "invalid body",
+			body:           `{"user_id":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid request body",
+		},
+		{
+			name:           "not an owner",
+			body:           `{"user_id":"7","role":"member"}`,
+			serviceErr:     suberrors.ErrForbidden,
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "Forbidden",
+		},
+		{
+			name:           "chat not found",
+			body:           `{"user_id":"7","role":"member"}`,
+			serviceErr:     suberrors.ErrChatNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Chat not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+			if tc.serviceErr != nil {
+				srv.EXPECT().AddChatMember(gomock.Any(), nil, "1", gomock.Any()).Return(nil, tc.serviceErr).Times(1)
+			}
+			server := NewHiTalentServer(cfg, srv, ctx)
+
+			req := httptest.NewRequest("POST", "/api/v1/chats/1/members", strings.NewReader(tc.body))
+			req.SetPathValue("id", "1")
+
+			w := httptest.NewRecorder()
+
+			AddChatMemberHandler(server)(w, req)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			require.Contains(t, w.Body.String(), tc.expectedError)
+		})
+	}
+}
+
+func TestRemoveChatMemberHandler_NotFound(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().RemoveChatMember(gomock.Any(), nil, "1", "99").Return(suberrors.ErrMemberNotFound).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	req := httptest.NewRequest("DELETE", "/api/v1/chats/1/members/99", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("userId", "99")
+
+	w := httptest.NewRecorder()
+
+	RemoveChatMemberHandler(server)(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Contains(t, w.Body.String(), "Member not found")
+}
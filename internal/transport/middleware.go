@@ -0,0 +1,264 @@
+package transport
+
+import (
+	"TestHitalent/pkg/logger"
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLength bounds client-supplied ids before they reach logs.
+	maxRequestIDLength = 128
+)
+
+// Middleware wraps a handler with behaviour shared by all routes.
+type Middleware func(http.Handler) http.Handler
+
+// Chain applies middlewares so that the first one sees the request first.
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the id RequestIDMiddleware assigned to the request.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDMiddleware keeps the caller's X-Request-ID or generates one, echoes
+// it in the response and puts a logger tagged with it into the request context.
+func RequestIDMiddleware(s *HiTalentServer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = logger.WithLogger(ctx, logger.GetLoggerFromCtx(s.ctx).With(zap.String("request_id", id)))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AccessLogMiddleware logs every request once it has been served. Streaming
+// endpoints are logged when the stream ends.
+func AccessLogMiddleware(s *HiTalentServer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r)
+
+		s.log(r).Info("request served",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", rec.Status()),
+			zap.Duration("latency", time.Since(start)),
+			zap.Int64("bytes", rec.bytes),
+			zap.String("remote_addr", r.RemoteAddr),
+		)
+	})
+}
+
+// RecoverMiddleware turns a handler panic into a 500 problem response.
+func RecoverMiddleware(s *HiTalentServer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &responseRecorder{ResponseWriter: w}
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			s.log(r).Error("handler panicked",
+				zap.Any("panic", v),
+				zap.ByteString("stack", debug.Stack()),
+			)
+			if rec.status == 0 {
+				writeProblemBody(rec, r, newProblem(problemInternal, ""))
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// metricsMiddleware records every request under the pattern routes matched
+// it with.
+func (s *HiTalentServer) metricsMiddleware(routes *http.ServeMux) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &responseRecorder{ResponseWriter: w}
+
+			next.ServeHTTP(rec, r)
+
+			s.metrics.ObserveRequest(r.Method, routePattern(routes, r), rec.Status(), time.Since(start))
+		})
+	}
+}
+
+// tracingMiddleware starts the server span of a request as a child of the
+// W3C traceparent the caller sent, if any, and tags the request logger with
+// the trace so log entries can be found from a trace and back.
+func (s *HiTalentServer) tracingMiddleware(routes *http.ServeMux) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routePattern(routes, r)
+			ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := s.tracer.Start(ctx, route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("http.route", route),
+					attribute.String("url.path", r.URL.Path),
+					attribute.String("http.request.id", RequestIDFromContext(ctx)),
+				),
+			)
+			defer span.End()
+
+			sc := span.SpanContext()
+			ctx = logger.WithLogger(ctx, s.log(r).With(
+				zap.String("trace_id", sc.TraceID().String()),
+				zap.String("span_id", sc.SpanID().String()),
+			))
+
+			rec := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			status := rec.Status()
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
+
+// propagator reads W3C trace context and baggage from request headers.
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// routePattern returns the pattern routes serves r with, so chat ids in paths
+// do not end up in metric labels or span names.
+func routePattern(routes *http.ServeMux, r *http.Request) string {
+	if _, pattern := routes.Handler(r); pattern != "" {
+		return pattern
+	}
+	return "unmatched"
+}
+
+func (s *HiTalentServer) with(m func(*HiTalentServer, http.Handler) http.Handler) Middleware {
+	return func(next http.Handler) http.Handler {
+		return m(s, next)
+	}
+}
+
+// log returns the request-scoped logger, or the server's one for requests
+// that did not pass through RequestIDMiddleware.
+func (s *HiTalentServer) log(r *http.Request) *logger.Logger {
+	if l, ok := r.Context().Value(logger.Key).(*logger.Logger); ok {
+		return l
+	}
+	return logger.GetLoggerFromCtx(s.ctx)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// responseRecorder remembers the status and size of a response. It keeps the
+// Flusher and Hijacker of the underlying writer so SSE and WebSocket work.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Status is the status sent to the client. A hijacked connection reports 101.
+func (w *responseRecorder) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *responseRecorder) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not support hijacking")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
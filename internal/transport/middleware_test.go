@@ -0,0 +1,207 @@
+package transport
+
+import (
+	"TestHitalent/internal/config"
+	"TestHitalent/internal/metrics"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/service/mocks"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+var apiKeyPrincipal = &models.Principal{ID: "api_key:1", Name: "ci", Method: models.AuthMethodAPIKey}
+
+func newMiddlewareTestServer(t *testing.T, srv *mocks.MockHiTalentServiceInterface) *HiTalentServer {
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	return NewHiTalentServer(cfg, srv, context.Background())
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	cases := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{name: "generated", requestID: "", keep: false},
+		{name: "propagated", requestID: "req-123", keep: true},
+		{name: "too long", requestID: strings.Repeat("a", maxRequestIDLength+1), keep: false},
+		{name: "control characters", requestID: "req\n123", keep: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			server := newMiddlewareTestServer(t, mocks.NewMockHiTalentServiceInterface(ctl))
+
+			var seen string
+			handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = RequestIDFromContext(r.Context())
+				server.log(r).Info("inside handler")
+			}), server.with(RequestIDMiddleware), server.with(AccessLogMiddleware))
+
+			req := httptest.NewRequest("GET", "/api/v1/chats", nil)
+			if tc.requestID != "" {
+				req.Header.Set(RequestIDHeader, tc.requestID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			require.Equal(t, seen, id)
+			if tc.keep {
+				require.Equal(t, tc.requestID, id)
+			} else {
+				require.Len(t, id, 32)
+			}
+		})
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(1)
+	srv.EXPECT().DeleteChat(gomock.Any(), apiKeyPrincipal, "1").DoAndReturn(func(context.Context, *models.Principal, string) error {
+		panic(`boom "quoted"`)
+	}).Times(1)
+
+	server := newMiddlewareTestServer(t, srv)
+
+	req := httptest.NewRequest("DELETE", "/api/v1/chats/1", nil)
+	req.Header.Set("X-API-Key", "htk_key")
+	w := httptest.NewRecorder()
+
+	server.Handler().ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.NotEmpty(t, w.Header().Get(RequestIDHeader))
+	require.NotContains(t, w.Body.String(), "boom")
+
+	var problem Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+	require.Equal(t, "internal_error", problem.Code)
+}
+
+func TestHandler_WebSocketThroughMiddleware(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(1)
+
+	stream := make(chan *models.Event, 1)
+	srv.EXPECT().Subscribe(gomock.Any(), apiKeyPrincipal, "1", 0).Return(stream, func() {}, nil).Times(1)
+
+	ts := httptest.NewServer(newMiddlewareTestServer(t, srv).Handler())
+	defer ts.Close()
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/v1/chats/1/ws?access_token=htk_key", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NotEmpty(t, resp.Header.Get(RequestIDHeader))
+
+	stream <- &models.Event{Type: models.EventChatDeleted, ChatID: 1}
+
+	var event models.Event
+	require.NoError(t, conn.ReadJSON(&event))
+	require.Equal(t, models.EventChatDeleted, event.Type)
+}
+
+func TestHandler_Metrics(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(1)
+	srv.EXPECT().DeleteChat(gomock.Any(), apiKeyPrincipal, "7").Return(suberrors.ErrChatNotFound).Times(1)
+	srv.EXPECT().Authenticate(gomock.Any(), "").Return(nil, suberrors.ErrUnauthorized).Times(1)
+
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	handler := NewHiTalentServer(cfg, srv, context.Background(), WithMetrics(metrics.New())).Handler()
+
+	req := httptest.NewRequest("DELETE", "/api/v1/chats/7", nil)
+	req.Header.Set("X-API-Key", "htk_key")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/unknown", nil))
+
+	// Scrapes need no credentials.
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	require.Contains(t, body, `hitalent_http_requests_total{method="DELETE",route="DELETE /api/v1/chats/{id}",status="404"} 1`)
+	require.Contains(t, body, `hitalent_http_requests_total{method="GET",route="unmatched",status="401"} 1`)
+	require.NotContains(t, body, `route="/metrics"`)
+}
+
+func TestHandler_Tracing(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+
+	var serviceSpan trace.SpanContext
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(1)
+	srv.EXPECT().DeleteChat(gomock.Any(), apiKeyPrincipal, "7").DoAndReturn(func(ctx context.Context, _ *models.Principal, _ string) error {
+		serviceSpan = trace.SpanContextFromContext(ctx)
+		return nil
+	}).Times(1)
+
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	handler := NewHiTalentServer(cfg, srv, context.Background(), WithTracing(tp)).Handler()
+
+	req := httptest.NewRequest("DELETE", "/api/v1/chats/7", nil)
+	req.Header.Set("X-API-Key", "htk_key")
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	require.Equal(t, "DELETE /api/v1/chats/{id}", span.Name)
+	require.Equal(t, trace.SpanKindServer, span.SpanKind)
+	require.Equal(t, traceID, span.SpanContext.TraceID().String())
+	require.Equal(t, parentSpanID, span.Parent.SpanID().String())
+	require.Contains(t, span.Attributes, attribute.Int("http.response.status_code", http.StatusNoContent))
+	require.Contains(t, span.Attributes, attribute.String("http.request.id", w.Header().Get(RequestIDHeader)))
+
+	// The service runs inside the request span.
+	require.Equal(t, span.SpanContext.SpanID(), serviceSpan.SpanID())
+}
@@ -0,0 +1,37 @@
+package transport
+
+import (
+	_ "embed"
+	"net/http"
+
+	swaggerFiles "github.com/swaggo/files"
+)
+
+// openAPISpec describes every route of the server. TestOpenAPI keeps it in
+// step with the handlers.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+//go:embed docs.html
+var docsPage []byte
+
+// OpenAPIHandler serves the OpenAPI document of the API.
+func OpenAPIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openAPISpec)
+	}
+}
+
+// DocsHandler serves Swagger UI for /openapi.json under /docs/. The UI assets
+// are compiled into the binary, so the docs work without internet access.
+func DocsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /docs/{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(docsPage)
+	})
+	mux.Handle("GET /docs/", http.StripPrefix("/docs", http.FileServer(swaggerFiles.HTTP)))
+	return mux
+}
@@ -0,0 +1,341 @@
+package transport
+
+import (
+	"TestHitalent/internal/config"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/service/mocks"
+	"TestHitalent/pkg/suberrors"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func loadOpenAPI(t *testing.T) *openapi3.T {
+	t.Helper()
+
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+	return doc
+}
+
+func TestOpenAPI_DescribesEveryRoute(t *testing.T) {
+	doc := loadOpenAPI(t)
+	server := newMiddlewareTestServer(t, nil)
+
+	registered := map[string]bool{}
+	for _, rt := range server.routes() {
+		method, path, _ := strings.Cut(rt.pattern, " ")
+		registered[method+" "+path] = true
+
+		item := doc.Paths.Value(path)
+		require.NotNil(t, item, "%s is not in openapi.json", path)
+		require.NotNil(t, item.GetOperation(method), "%s is not in openapi.json", rt.pattern)
+	}
+
+	// And nothing in the document is left without a handler.
+	for path, item := range doc.Paths.Map() {
+		if !strings.HasPrefix(path, "/api/") {
+			continue
+		}
+		for method := range item.Operations() {
+			require.True(t, registered[method+" "+path], "%s %s has no handler", method, path)
+		}
+	}
+}
+
+func TestOpenAPI_ResponsesConform(t *testing.T) {
+	doc := loadOpenAPI(t)
+	router, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+
+	created := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	edited := created.Add(time.Minute)
+	chat := &models.Chat{ID: 1, Title: "General", CreatedAt: created, UpdatedAt: created, Version: 2}
+	message := &models.Message{ID: 5, ChatID: 1, SenderID: "user-42", SenderName: "Alice", Text: "Hello", CreatedAt: created}
+	editedMessage := &models.Message{ID: 6, ChatID: 1, Text: "Hi", CreatedAt: created, EditedAt: &edited}
+	deletedMessage := &models.Message{ID: 7, ChatID: 1, Text: models.DeletedMessagePlaceholder, CreatedAt: created, DeletedAt: &edited}
+	member := &models.ChatMember{ChatID: 1, UserID: "user-42", Role: models.ChatRoleOwner, CreatedAt: created}
+	validationErr := validator.New().Struct(&models.Chat{})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     map[string]string
+		body       string
+		setup      func(srv *mocks.MockHiTalentServiceInterface)
+		wantStatus int
+	}{
+		{
+			name: "create chat", method: "POST", path: "/api/v1/chats", body: `{"title":"General"}`,
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().CreateChat(gomock.Any(), gomock.Any(), gomock.Any()).Return(chat, nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "create chat with messages", method: "POST", path: "/api/v1/chats", body: `{"title":"General","messages":[{"text":"Hello"}]}`,
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				seeded := *chat
+				seeded.Messages = []*models.Message{message}
+				srv.EXPECT().CreateChat(gomock.Any(), gomock.Any(), gomock.Any()).Return(&seeded, nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "create chat with invalid body", method: "POST", path: "/api/v1/chats", body: `{`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "create chat failing validation", method: "POST", path: "/api/v1/chats", body: `{"title":""}`,
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().CreateChat(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, validationErr)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "import messages", method: "POST", path: "/api/v1/chats/1/messages:batch",
+			body: `{"messages":[{"text":"Hello","created_at":"2026-03-10T12:00:00Z"},{"text":""}]}`,
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().CreateMessageBatch(gomock.Any(), gomock.Any(), "1", gomock.Any()).Return([]*models.MessageBatchResult{
+					{Index: 0, Message: message},
+					{Index: 1, Err: validationErr},
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "import too many messages", method: "POST", path: "/api/v1/chats/1/messages:batch", body: `{"messages":[{"text":"Hello"}]}`,
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().CreateMessageBatch(gomock.Any(), gomock.Any(), "1", gomock.Any()).Return(nil, suberrors.ErrMessageBatchTooLarge)
+			},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "list chats", method: "GET", path: "/api/v1/chats?sort=last_activity",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				listed := *chat
+				listed.LastActivityAt = &edited
+				srv.EXPECT().ListChats(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.ChatListResponse{Chats: []*models.Chat{&listed}, NextCursor: "abc", HasMore: true}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "list chats with invalid cursor", method: "GET", path: "/api/v1/chats?cursor=!",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "get chat", method: "GET", path: "/api/v1/chats/1",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().GetChat(gomock.Any(), gomock.Any(), "1", gomock.Any()).Return(&models.ChatAndMessagesResponse{
+					Chat:     chat,
+					Messages: []*models.Message{deletedMessage, editedMessage, message},
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "get missing chat", method: "GET", path: "/api/v1/chats/2",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().GetChat(gomock.Any(), gomock.Any(), "2", gomock.Any()).Return(nil, suberrors.ErrChatNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "rename chat", method: "PATCH", path: "/api/v1/chats/1", header: map[string]string{"If-Match": `"2"`}, body: `{"title":"Renamed"}`,
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().UpdateChat(gomock.Any(), gomock.Any(), "1", gomock.Any(), 2).Return(chat, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "rename chat without If-Match", method: "PATCH", path: "/api/v1/chats/1", body: `{"title":"Renamed"}`,
+			wantStatus: http.StatusPreconditionRequired,
+		},
+		{
+			name: "rename modified chat", method: "PATCH", path: "/api/v1/chats/1", header: map[string]string{"If-Match": `"1"`}, body: `{"title":"Renamed"}`,
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().UpdateChat(gomock.Any(), gomock.Any(), "1", gomock.Any(), 1).Return(nil, suberrors.ErrChatVersionMismatch)
+			},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "delete chat", method: "DELETE", path: "/api/v1/chats/1",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().DeleteChat(gomock.Any(), gomock.Any(), "1").Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "delete chat failing internally", method: "DELETE", path: "/api/v1/chats/1",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().DeleteChat(gomock.Any(), gomock.Any(), "1").Return(errors.New("connection reset"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "post message", method: "POST", path: "/api/v1/chats/1/messages", body: `{"text":"Hello"}`,
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().CreateMessage(gomock.Any(), gomock.Any(), "1", gomock.Any()).Return(message, nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "post message to read-only chat", method: "POST", path: "/api/v1/chats/1/messages", body: `{"text":"Hello"}`,
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().CreateMessage(gomock.Any(), gomock.Any(), "1", gomock.Any()).Return(nil, suberrors.ErrForbidden)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "edit message", method: "PATCH", path: "/api/v1/chats/1/messages/6", body: `{"text":"Hi"}`,
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().UpdateMessage(gomock.Any(), gomock.Any(), "1", "6", gomock.Any()).Return(editedMessage, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "delete message", method: "DELETE", path: "/api/v1/chats/1/messages/7",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().DeleteMessage(gomock.Any(), gomock.Any(), "1", "7").Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "search", method: "GET", path: "/api/v1/search?q=hello",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any()).Return(&models.SearchResponse{
+					Results: []*models.SearchResult{{Message: message, Rank: 0.6, Snippet: "<mark>Hello</mark>"}},
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "search without query", method: "GET", path: "/api/v1/search",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, suberrors.ErrEmptySearchQuery)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "search chat", method: "GET", path: "/api/v1/chats/1/messages/search?q=hello",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().SearchChat(gomock.Any(), gomock.Any(), "1", gomock.Any()).Return(&models.SearchResponse{Results: []*models.SearchResult{}}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "events of missing chat", method: "GET", path: "/api/v1/chats/2/events",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().Subscribe(gomock.Any(), gomock.Any(), "2", 0).Return(nil, nil, suberrors.ErrChatNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "list members", method: "GET", path: "/api/v1/chats/1/members",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().ListChatMembers(gomock.Any(), gomock.Any(), "1").Return([]*models.ChatMember{member}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "add member", method: "POST", path: "/api/v1/chats/1/members", body: `{"user_id":"user-42","role":"owner"}`,
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().AddChatMember(gomock.Any(), gomock.Any(), "1", gomock.Any()).Return(member, nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "remove last owner", method: "DELETE", path: "/api/v1/chats/1/members/user-42",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().RemoveChatMember(gomock.Any(), gomock.Any(), "1", "user-42").Return(suberrors.ErrLastOwner)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "remove member", method: "DELETE", path: "/api/v1/chats/1/members/user-7",
+			setup: func(srv *mocks.MockHiTalentServiceInterface) {
+				srv.EXPECT().RemoveChatMember(gomock.Any(), gomock.Any(), "1", "user-7").Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "unauthenticated", method: "GET", path: "/api/v1/chats", header: map[string]string{"X-API-Key": ""},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{name: "liveness", method: "GET", path: "/healthz", wantStatus: http.StatusOK},
+		{name: "readiness", method: "GET", path: "/readyz", wantStatus: http.StatusOK},
+		{name: "document", method: "GET", path: "/openapi.json", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+			srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).AnyTimes()
+			srv.EXPECT().Authenticate(gomock.Any(), "").Return(nil, suberrors.ErrUnauthorized).AnyTimes()
+			if tt.setup != nil {
+				tt.setup(srv)
+			}
+			cfg := &config.Config{
+				Host: "localhost",
+				Port: "4047",
+			}
+			handler := NewHiTalentServer(cfg, srv, context.Background()).Handler()
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("X-API-Key", "htk_key")
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+
+			route, pathParams, err := router.FindRoute(req)
+			require.NoError(t, err)
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    req,
+					PathParams: pathParams,
+					Route:      route,
+				},
+				Status:  w.Code,
+				Header:  w.Header(),
+				Body:    io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+				Options: &openapi3filter.Options{IncludeResponseStatus: true},
+			})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestHandler_Docs(t *testing.T) {
+	handler := newMiddlewareTestServer(t, nil).Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/docs/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `url: "/openapi.json"`)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/docs/swagger-ui-bundle.js", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Header().Get("Content-Type"), "javascript")
+}
@@ -0,0 +1,136 @@
+package transport
+
+import (
+	"TestHitalent/internal/auth"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/ratelimit"
+	"TestHitalent/pkg/logger"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// WithRateLimit limits how fast each client may post messages, keyed as cfg
+// says.
+func WithRateLimit(limiter ratelimit.Limiter, cfg ratelimit.Config) Option {
+	return func(s *HiTalentServer) {
+		s.rateLimiter = limiter
+		s.rateLimit = cfg
+	}
+}
+
+// rateLimited takes a token for the caller before next runs and answers 429
+// when there is none. It runs inside the mux, after authentication, so the
+// principal and the chat id are known.
+func (s *HiTalentServer) rateLimited(next http.HandlerFunc) http.HandlerFunc {
+	if s.rateLimiter == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.takeTokens(w, r, 1) {
+			next(w, r)
+		}
+	}
+}
+
+// takeTokens takes n tokens for the caller, one per message posted, and
+// reports whether the request may go on. When it may not, the 429 has been
+// written. If the limiter fails the request is let through: an unavailable
+// limiter should not take posting down with it.
+func (s *HiTalentServer) takeTokens(w http.ResponseWriter, r *http.Request, n int) bool {
+	if s.rateLimiter == nil {
+		return true
+	}
+
+	d, err := s.rateLimiter.Allow(r.Context(), s.rateLimitKey(r), n)
+	if err != nil {
+		s.log(r).Error("rate limiter failed, request let through", zap.Error(err))
+		return true
+	}
+
+	h := w.Header()
+	h.Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	h.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+	if d.Allowed {
+		return true
+	}
+
+	if n > d.Limit {
+		// No wait makes room for it, so there is no Retry-After.
+		s.writeProblem(w, r, problemRateLimited, fmt.Sprintf("%d messages exceed the rate limit burst of %d", n, d.Limit))
+		return false
+	}
+	h.Set("Retry-After", strconv.Itoa(max(ceilSeconds(d.RetryAfter), 1)))
+	s.writeProblem(w, r, problemRateLimited, "message rate limit exceeded")
+	return false
+}
+
+// rateLimitFrame takes a token for a message sent over a WebSocket, from the
+// bucket of the request that opened it, and returns the problem to reply with
+// when there is none. A frame carries no headers, so the wait is in the detail.
+func (s *HiTalentServer) rateLimitFrame(ctx context.Context, log *logger.Logger, key string) *Problem {
+	if s.rateLimiter == nil {
+		return nil
+	}
+
+	d, err := s.rateLimiter.Allow(ctx, key, 1)
+	if err != nil {
+		log.Error("rate limiter failed, message let through", zap.Error(err))
+		return nil
+	}
+	if d.Allowed {
+		return nil
+	}
+	return newProblem(problemRateLimited, fmt.Sprintf("message rate limit exceeded, retry in %d s", max(ceilSeconds(d.RetryAfter), 1)))
+}
+
+// rateLimitKey names the bucket of the caller: the authenticated principal or
+// the client address, optionally narrowed to the chat posted to.
+func (s *HiTalentServer) rateLimitKey(r *http.Request) string {
+	var key string
+	if p := auth.PrincipalFromContext(r.Context()); s.rateLimit.KeyBy == ratelimit.KeyByPrincipal && p != nil {
+		key = principalKey(p)
+	} else {
+		key = "ip:" + clientIP(r)
+	}
+
+	if s.rateLimit.PerChat {
+		id := r.PathValue("id")
+		// "7" and "007" are the same chat and must share a bucket.
+		if n, err := strconv.Atoi(id); err == nil {
+			id = strconv.Itoa(n)
+		}
+		key += "|chat:" + id
+	}
+	return key
+}
+
+// principalKey identifies the caller across requests. The method is part of
+// it so a JWT subject cannot collide with an API key id.
+func principalKey(p *models.Principal) string {
+	if p == nil {
+		return "anonymous"
+	}
+	return "principal:" + p.Method + ":" + p.ID
+}
+
+// clientIP is the address of the peer. X-Forwarded-For is ignored, since any
+// client can set it to get a fresh bucket.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,175 @@
+package transport
+
+import (
+	"TestHitalent/internal/config"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/ratelimit"
+	"TestHitalent/internal/service/mocks"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type stubLimiter struct {
+	keys     []string
+	decision ratelimit.Decision
+	err      error
+}
+
+func (l *stubLimiter) Allow(ctx context.Context, key string, n int) (ratelimit.Decision, error) {
+	l.keys = append(l.keys, key)
+	return l.decision, l.err
+}
+
+func newRateLimitTestHandler(srv *mocks.MockHiTalentServiceInterface, limiter ratelimit.Limiter, cfg ratelimit.Config) http.Handler {
+	serverCfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	return NewHiTalentServer(serverCfg, srv, context.Background(), WithRateLimit(limiter, cfg)).Handler()
+}
+
+func postMessage(handler http.Handler, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(`{"text":"Hello"}`))
+	req.Header.Set("X-API-Key", "htk_key")
+	req.RemoteAddr = "203.0.113.7:52000"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_RateLimit(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(3)
+	srv.EXPECT().CreateMessage(gomock.Any(), apiKeyPrincipal, "1", gomock.Any()).Return(&models.Message{ID: 1, ChatID: 1, Text: "Hello"}, nil).Times(2)
+
+	limiter := ratelimit.NewMemory(1, 2)
+	handler := newRateLimitTestHandler(srv, limiter, ratelimit.Config{KeyBy: ratelimit.KeyByPrincipal})
+
+	w := postMessage(handler, "/api/v1/chats/1/messages")
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	require.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+
+	w = postMessage(handler, "/api/v1/chats/1/messages")
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	w = postMessage(handler, "/api/v1/chats/1/messages")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "1", w.Header().Get("Retry-After"))
+	require.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	require.Equal(t, "2", w.Header().Get("X-RateLimit-Reset"))
+
+	var p Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	require.Equal(t, "rate_limited", p.Code)
+}
+
+func TestHandler_RateLimitBatch(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(3)
+	srv.EXPECT().CreateMessageBatch(gomock.Any(), apiKeyPrincipal, "1", gomock.Len(2)).
+		Return([]*models.MessageBatchResult{{Index: 0, Message: &models.Message{ID: 1}}, {Index: 1, Message: &models.Message{ID: 2}}}, nil).Times(1)
+
+	limiter := ratelimit.NewMemory(1, 3)
+	handler := newRateLimitTestHandler(srv, limiter, ratelimit.Config{KeyBy: ratelimit.KeyByPrincipal})
+
+	importMessages := func(n int) *httptest.ResponseRecorder {
+		body := `{"messages":[` + strings.Repeat(`{"text":"Hello"},`, n-1) + `{"text":"Hello"}]}`
+		req := httptest.NewRequest("POST", "/api/v1/chats/1/messages:batch", strings.NewReader(body))
+		req.Header.Set("X-API-Key", "htk_key")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// A batch larger than the bucket is refused outright.
+	w := importMessages(4)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Empty(t, w.Header().Get("Retry-After"))
+	require.Equal(t, "3", w.Header().Get("X-RateLimit-Remaining"))
+
+	// Each message takes a token.
+	w = importMessages(2)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+
+	w = importMessages(2)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	var p Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	require.Equal(t, "rate_limited", p.Code)
+}
+
+func TestHandler_RateLimitKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ratelimit.Config
+		path    string
+		wantKey string
+	}{
+		{
+			name:    "principal",
+			cfg:     ratelimit.Config{KeyBy: ratelimit.KeyByPrincipal},
+			path:    "/api/v1/chats/1/messages",
+			wantKey: "principal:api_key:api_key:1",
+		},
+		{
+			name:    "ip",
+			cfg:     ratelimit.Config{KeyBy: ratelimit.KeyByIP},
+			path:    "/api/v1/chats/1/messages",
+			wantKey: "ip:203.0.113.7",
+		},
+		{
+			name:    "per chat with padded id",
+			cfg:     ratelimit.Config{KeyBy: ratelimit.KeyByPrincipal, PerChat: true},
+			path:    "/api/v1/chats/007/messages",
+			wantKey: "principal:api_key:api_key:1|chat:7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+			srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(1)
+
+			limiter := &stubLimiter{decision: ratelimit.Decision{Limit: 1, RetryAfter: time.Second}}
+			postMessage(newRateLimitTestHandler(srv, limiter, tt.cfg), tt.path)
+			require.Equal(t, []string{tt.wantKey}, limiter.keys)
+		})
+	}
+}
+
+func TestHandler_RateLimitFailsOpen(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(apiKeyPrincipal, nil).Times(1)
+	srv.EXPECT().CreateMessage(gomock.Any(), apiKeyPrincipal, "1", gomock.Any()).Return(&models.Message{ID: 1, ChatID: 1, Text: "Hello"}, nil).Times(1)
+
+	limiter := &stubLimiter{err: errors.New("connection refused")}
+	w := postMessage(newRateLimitTestHandler(srv, limiter, ratelimit.Config{KeyBy: ratelimit.KeyByPrincipal}), "/api/v1/chats/1/messages")
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+}
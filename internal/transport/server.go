@@ -1,205 +1,541 @@
 package transport
 
 import (
+	"TestHitalent/internal/auth"
 	"TestHitalent/internal/config"
+	"TestHitalent/internal/idempotency"
+	"TestHitalent/internal/metrics"
 	"TestHitalent/internal/models"
+	"TestHitalent/internal/ratelimit"
 	"TestHitalent/pkg/logger"
 	"TestHitalent/pkg/suberrors"
+	"TestHitalent/pkg/tracing"
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
 //go:generate mockgen -source=server.go -destination=../service/mocks/mock_service.go -package=mocks HiTalentServiceInterface
 
 type HiTalentServiceInterface interface {
-	CreateChat(chat *models.Chat) (*models.Chat, error)
-	CreateMessage(chatId string, message *models.Message) (*models.Message, error)
-	GetChat(chatId string, limit int) (*models.ChatAndMessagesResponse, error)
-	DeleteChat(chatId string) error
+	Authenticate(ctx context.Context, credential string) (*models.Principal, error)
+	CreateChat(ctx context.Context, principal *models.Principal, chat *models.Chat) (*models.Chat, error)
+	CreateMessage(ctx context.Context, principal *models.Principal, chatId string, message *models.Message) (*models.Message, error)
+	CreateMessageBatch(ctx context.Context, principal *models.Principal, chatId string, messages []*models.Message) ([]*models.MessageBatchResult, error)
+	GetChat(ctx context.Context, principal *models.Principal, chatId string, query *models.MessagesQuery) (*models.ChatAndMessagesResponse, error)
+	ListChats(ctx context.Context, principal *models.Principal, query *models.ChatsQuery) (*models.ChatListResponse, error)
+	UpdateChat(ctx context.Context, principal *models.Principal, chatId string, chat *models.Chat, version int) (*models.Chat, error)
+	DeleteChat(ctx context.Context, principal *models.Principal, chatId string) error
+	UpdateMessage(ctx context.Context, principal *models.Principal, chatId string, messageId string, message *models.Message) (*models.Message, error)
+	DeleteMessage(ctx context.Context, principal *models.Principal, chatId string, messageId string) error
+	Subscribe(ctx context.Context, principal *models.Principal, chatId string, lastEventId int) (<-chan *models.Event, func(), error)
+	Search(ctx context.Context, principal *models.Principal, query *models.SearchQuery) (*models.SearchResponse, error)
+	SearchChat(ctx context.Context, principal *models.Principal, chatId string, query *models.SearchQuery) (*models.SearchResponse, error)
+	ListChatMembers(ctx context.Context, principal *models.Principal, chatId string) ([]*models.ChatMember, error)
+	AddChatMember(ctx context.Context, principal *models.Principal, chatId string, member *models.ChatMember) (*models.ChatMember, error)
+	RemoveChatMember(ctx context.Context, principal *models.Principal, chatId string, userId string) error
 }
 
 type HiTalentServer struct {
 	cfg     *config.Config
 	service HiTalentServiceInterface
 	ctx     context.Context
+	metrics *metrics.Metrics
+	tracer  trace.Tracer
+
+	httpServer *http.Server
+	// draining is closed when shutdown starts, ending open event streams so
+	// their clients reconnect elsewhere instead of holding up the drain.
+	draining  chan struct{}
+	drainOnce sync.Once
+
+	readinessChecks []HealthCheck
+
+	rateLimiter ratelimit.Limiter
+	rateLimit   ratelimit.Config
+	idempotency idempotency.Store
+}
+
+// Option enables an optional server feature.
+type Option func(*HiTalentServer)
+
+// WithMetrics records request metrics and serves them at /metrics.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(s *HiTalentServer) {
+		s.metrics = m
+	}
+}
+
+// WithTracing records a server span for every API request, continuing the
+// trace of the caller's traceparent header.
+func WithTracing(tp trace.TracerProvider) Option {
+	return func(s *HiTalentServer) {
+		s.tracer = tracing.Tracer(tp)
+	}
 }
 
-func NewHiTalentServer(cfg *config.Config, service HiTalentServiceInterface, ctx context.Context) *HiTalentServer {
-	return &HiTalentServer{
-		cfg:     cfg,
-		service: service,
-		ctx:     ctx,
+func NewHiTalentServer(cfg *config.Config, service HiTalentServiceInterface, ctx context.Context, opts ...Option) *HiTalentServer {
+	s := &HiTalentServer{
+		cfg:      cfg,
+		service:  service,
+		ctx:      ctx,
+		draining: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	s.httpServer = &http.Server{
+		Addr:         cfg.Host + ":" + cfg.Port,
+		Handler:      s.Handler(),
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+	return s
 }
 
+// Run serves on the configured address until Shutdown is called, after which
+// it returns nil.
 func (s *HiTalentServer) Run() error {
+	l, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve is Run on a listener the caller opened.
+func (s *HiTalentServer) Serve(l net.Listener) error {
+	logger.GetLoggerFromCtx(s.ctx).Info("HTTP server is running", zap.String("address", l.Addr().String()))
+	if err := s.httpServer.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown fails readiness and closes event streams, keeps accepting
+// connections for the configured shutdown delay, then stops accepting them
+// and waits for in-flight requests to finish until ctx is done.
+func (s *HiTalentServer) Shutdown(ctx context.Context) error {
+	s.drainOnce.Do(func() {
+		close(s.draining)
+	})
+
+	if s.cfg.ShutdownDelay > 0 {
+		timer := time.NewTimer(s.cfg.ShutdownDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+	return s.httpServer.Shutdown(ctx)
+}
+
+// Handler returns the API routes wrapped in the middleware every API request
+// passes through. Operational endpoints such as /metrics and the health
+// probes bypass it.
+func (s *HiTalentServer) Handler() http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("POST /api/v1/chats", CreateChatHandler(s))
-	mux.HandleFunc("POST /api/v1/chats/{id}/messages", CreateMessageHandler(s))
-	mux.HandleFunc("GET /api/v1/chats/{id}", GetChatHandler(s))
-	mux.HandleFunc("DELETE /api/v1/chats/{id}", DeleteChatHandler(s))
-	logger.GetLoggerFromCtx(s.ctx).Info("HTTP server is running")
-	addr := s.cfg.Host + ":" + s.cfg.Port
-	return http.ListenAndServe(addr, mux)
+	for _, rt := range s.routes() {
+		mux.HandleFunc(rt.pattern, rt.handler)
+	}
+
+	middlewares := []Middleware{s.with(RequestIDMiddleware)}
+	if s.tracer != nil {
+		middlewares = append(middlewares, s.tracingMiddleware(mux))
+	}
+	if s.metrics != nil {
+		middlewares = append(middlewares, s.metricsMiddleware(mux))
+	}
+	middlewares = append(middlewares,
+		s.with(AccessLogMiddleware),
+		s.with(RecoverMiddleware),
+		s.with(AuthMiddleware),
+	)
+
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", LivenessHandler(s))
+	root.HandleFunc("GET /readyz", ReadinessHandler(s))
+	root.HandleFunc("GET /openapi.json", OpenAPIHandler())
+	root.Handle("GET /docs/", DocsHandler())
+	if s.metrics != nil {
+		root.Handle("GET /metrics", s.metrics.Handler())
+	}
+	root.Handle("/", Chain(mux, middlewares...))
+	return root
+}
+
+type route struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+// routes lists the API endpoints. Each one must be described in openapi.json.
+func (s *HiTalentServer) routes() []route {
+	return []route{
+		{"POST /api/v1/chats", s.idempotent(CreateChatHandler(s))},
+		// Replays are answered before the rate limiter and cost no tokens.
+		{"POST /api/v1/chats/{id}/messages", s.idempotent(s.rateLimited(CreateMessageHandler(s)))},
+		{"POST /api/v1/chats/{id}/messages:batch", s.idempotent(CreateMessageBatchHandler(s))},
+		{"GET /api/v1/chats", ListChatsHandler(s)},
+		{"GET /api/v1/chats/{id}", GetChatHandler(s)},
+		{"GET /api/v1/chats/{id}/events", ChatEventsHandler(s)},
+		{"GET /api/v1/chats/{id}/ws", ChatWebSocketHandler(s)},
+		{"GET /api/v1/chats/{id}/messages/search", SearchChatMessagesHandler(s)},
+		{"GET /api/v1/search", SearchHandler(s)},
+		{"GET /api/v1/chats/{id}/members", ListChatMembersHandler(s)},
+		{"POST /api/v1/chats/{id}/members", AddChatMemberHandler(s)},
+		{"DELETE /api/v1/chats/{id}/members/{userId}", RemoveChatMemberHandler(s)},
+		{"PATCH /api/v1/chats/{id}", UpdateChatHandler(s)},
+		{"DELETE /api/v1/chats/{id}", DeleteChatHandler(s)},
+		{"PATCH /api/v1/chats/{id}/messages/{msgId}", UpdateMessageHandler(s)},
+		{"DELETE /api/v1/chats/{id}/messages/{msgId}", DeleteMessageHandler(s)},
+	}
 }
 
 func CreateChatHandler(s *HiTalentServer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if rec := recover(); rec != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				_, _ = w.Write([]byte(`{"error": "Internal server error 1", "description": "` + fmt.Sprint(rec) + `"}`))
-			}
-		}()
-
 		defer r.Body.Close()
 		req := new(models.Chat)
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = w.Write([]byte(`{"error": "Invalid request body", "description": "` + err.Error() + `"}`))
+			s.writeProblem(w, r, problemInvalidBody, err.Error())
 			return
 		}
-		chat, err := s.service.CreateChat(req)
+		chat, err := s.service.CreateChat(r.Context(), auth.PrincipalFromContext(r.Context()), req)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte(`{"error": "Internal server error 2", "description": "` + err.Error() + `"}`))
+			s.writeError(w, r, err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-
-		err = json.NewEncoder(w).Encode(models.Chat{ID: chat.ID, Title: chat.Title, CreatedAt: chat.CreatedAt})
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte(`{"error": "Internal server error 3", "description": "` + err.Error() + `"}`))
-			return
-		}
+		w.Header().Set("ETag", chatETag(chat.Version))
+		s.writeJSON(w, r, http.StatusCreated, models.Chat{ID: chat.ID, Title: chat.Title, CreatedAt: chat.CreatedAt, UpdatedAt: chat.UpdatedAt, Version: chat.Version, Messages: chat.Messages})
 	}
 }
 
 func CreateMessageHandler(s *HiTalentServer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if rec := recover(); rec != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				_, _ = w.Write([]byte(`{"error": "Internal server error 1", "description": "` + fmt.Sprint(rec) + `"}`))
-				return
-			}
-		}()
-
 		id := r.PathValue("id")
 
 		defer r.Body.Close()
 
 		req := new(models.Message)
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = w.Write([]byte(`{"error": "Invalid request body", "description": "` + err.Error() + `"}`))
+			s.writeProblem(w, r, problemInvalidBody, err.Error())
+			return
+		}
+
+		msg, err := s.service.CreateMessage(r.Context(), auth.PrincipalFromContext(r.Context()), id, req)
+		if err != nil {
+			s.writeError(w, r, err)
 			return
 		}
+		s.writeJSON(w, r, http.StatusCreated, models.Message{ID: msg.ID, ChatID: msg.ChatID, SenderID: msg.SenderID, SenderName: msg.SenderName, Text: msg.Text, CreatedAt: msg.CreatedAt})
+	}
+}
+
+func GetChatHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
 
-		msg, err := s.service.CreateMessage(id, req)
+		limit, err := parseLimit(r)
 		if err != nil {
-			if errors.Is(err, suberrors.ErrChatNotFound) {
-				w.WriteHeader(http.StatusNotFound)
-				_, _ = w.Write([]byte(`{"error": "Chat not found"}`))
+			s.writeProblem(w, r, problemInvalidParameter, "limit: "+err.Error())
+			return
+		}
+
+		query := &models.MessagesQuery{Limit: limit, SenderID: r.URL.Query().Get("sender_id")}
+
+		before := r.URL.Query().Get("before")
+		after := r.URL.Query().Get("after")
+		if before != "" && after != "" {
+			s.writeProblem(w, r, problemInvalidParameter, "before and after are mutually exclusive")
+			return
+		}
+		if before != "" {
+			cursor, err := models.DecodeMessageCursor(before)
+			if err != nil {
+				s.writeError(w, r, err)
 				return
 			}
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte(`{"error": "Internal server error 2", "description": "` + err.Error() + `"}`))
-			return
+			query.Before = cursor
+		}
+		if after != "" {
+			cursor, err := models.DecodeMessageCursor(after)
+			if err != nil {
+				s.writeError(w, r, err)
+				return
+			}
+			query.After = cursor
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		err = json.NewEncoder(w).Encode(models.Message{ID: msg.ID, ChatID: msg.ChatID, Text: msg.Text, CreatedAt: msg.CreatedAt})
+
+		defer r.Body.Close()
+		chatAndMessage, err := s.service.GetChat(r.Context(), auth.PrincipalFromContext(r.Context()), id, query)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte(`{"error": "Internal server error 3", "description": "` + err.Error() + `"}`))
+			s.writeError(w, r, err)
 			return
 		}
+		w.Header().Set("ETag", chatETag(chatAndMessage.Version))
+		s.writeJSON(w, r, http.StatusOK, chatAndMessage)
 	}
 }
 
-func GetChatHandler(s *HiTalentServer) http.HandlerFunc {
+func ListChatsHandler(s *HiTalentServer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if rec := recover(); rec != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				_, _ = w.Write([]byte(`{"error": "Internal server error 1", "description": "` + fmt.Sprint(rec) + `"}`))
-				return
-			}
-		}()
-		id := r.PathValue("id")
+		limit, err := parseLimit(r)
+		if err != nil {
+			s.writeProblem(w, r, problemInvalidParameter, "limit: "+err.Error())
+			return
+		}
 
-		limitStr := r.URL.Query().Get("limit")
-		limit := 20
+		params := r.URL.Query()
+		query := &models.ChatsQuery{
+			Limit: limit,
+			Title: params.Get("title"),
+			Sort:  params.Get("sort"),
+			Order: params.Get("order"),
+		}
 
-		if limitStr != "" {
-			parsedLimit, err := strconv.Atoi(limitStr)
+		if v := params.Get("created_from"); v != "" {
+			createdFrom, err := time.Parse(time.RFC3339, v)
 			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				_, _ = w.Write([]byte(`{"error": "Invalid limit parameter", "description": "` + err.Error() + `"}`))
+				s.writeProblem(w, r, problemInvalidParameter, "created_from: "+err.Error())
 				return
 			}
-			limit = parsedLimit
-			if limit > 100 {
-				limit = 100
+			query.CreatedFrom = &createdFrom
+		}
+		if v := params.Get("created_to"); v != "" {
+			createdTo, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				s.writeProblem(w, r, problemInvalidParameter, "created_to: "+err.Error())
+				return
 			}
-			if limit < 1 {
-				limit = 1
+			query.CreatedTo = &createdTo
+		}
+		if v := params.Get("cursor"); v != "" {
+			cursor, err := models.DecodeChatCursor(v)
+			if err != nil {
+				s.writeError(w, r, err)
+				return
 			}
+			query.Cursor = cursor
 		}
 
 		defer r.Body.Close()
-		chatAndMessage, err := s.service.GetChat(id, limit)
+		chats, err := s.service.ListChats(r.Context(), auth.PrincipalFromContext(r.Context()), query)
 		if err != nil {
-			if errors.Is(err, suberrors.ErrChatNotFound) {
-				w.WriteHeader(http.StatusNotFound)
-				_, _ = w.Write([]byte(`{"error": "Chat not found"}`))
-				return
-			}
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte(`{"error": "Internal server error 2", "description": "` + err.Error() + `"}`))
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, r, http.StatusOK, chats)
+	}
+}
+
+func UpdateChatHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+
+		ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+		if ifMatch == "" {
+			s.writeProblem(w, r, problemPreconditionRequired, "send the chat ETag in If-Match, or * to overwrite unconditionally")
+			return
+		}
+
+		version, err := parseIfMatch(ifMatch)
+		if errors.Is(err, suberrors.ErrChatVersionMismatch) {
+			s.writeError(w, r, err)
 			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(chatAndMessage)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte(`{"error": "Internal server error 3", "description": "` + err.Error() + `"}`))
+			s.writeProblem(w, r, problemInvalidHeader, "If-Match: "+err.Error())
 			return
 		}
+
+		defer r.Body.Close()
+
+		req := new(models.Chat)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeProblem(w, r, problemInvalidBody, err.Error())
+			return
+		}
+
+		chat, err := s.service.UpdateChat(r.Context(), auth.PrincipalFromContext(r.Context()), id, req, version)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", chatETag(chat.Version))
+		s.writeJSON(w, r, http.StatusOK, chat)
 	}
 }
 
 func DeleteChatHandler(s *HiTalentServer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if rec := recover(); rec != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				_, _ = w.Write([]byte(`{"error": "Internal server error 1", "description": "` + fmt.Sprint(rec) + `"}`))
-				return
-			}
-		}()
 		id := r.PathValue("id")
 		defer r.Body.Close()
-		err := s.service.DeleteChat(id)
+		err := s.service.DeleteChat(r.Context(), auth.PrincipalFromContext(r.Context()), id)
 		if err != nil {
-			if errors.Is(err, suberrors.ErrChatNotFound) {
-				w.WriteHeader(http.StatusNotFound)
-				_, _ = w.Write([]byte(`{"error": "Chat not found"}`))
-				return
-			}
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte(`{"error": "Internal server error 2", "description": "` + err.Error() + `"}`))
+			s.writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func UpdateMessageHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		msgId := r.PathValue("msgId")
+
+		defer r.Body.Close()
+
+		req := new(models.Message)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeProblem(w, r, problemInvalidBody, err.Error())
+			return
+		}
+
+		msg, err := s.service.UpdateMessage(r.Context(), auth.PrincipalFromContext(r.Context()), id, msgId, req)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, r, http.StatusOK, msg)
+	}
+}
+
+func DeleteMessageHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		msgId := r.PathValue("msgId")
+		defer r.Body.Close()
+		err := s.service.DeleteMessage(r.Context(), auth.PrincipalFromContext(r.Context()), id, msgId)
+		if err != nil {
+			s.writeError(w, r, err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+func SearchHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, ok := s.parseSearchQuery(w, r)
+		if !ok {
+			return
+		}
+
+		defer r.Body.Close()
+		results, err := s.service.Search(r.Context(), auth.PrincipalFromContext(r.Context()), query)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, r, http.StatusOK, results)
+	}
+}
+
+func SearchChatMessagesHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+
+		query, ok := s.parseSearchQuery(w, r)
+		if !ok {
+			return
+		}
+
+		defer r.Body.Close()
+		results, err := s.service.SearchChat(r.Context(), auth.PrincipalFromContext(r.Context()), id, query)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		s.writeJSON(w, r, http.StatusOK, results)
+	}
+}
+
+// parseSearchQuery reads the q, limit and cursor parameters shared by both
+// search endpoints. On failure it has already written the error response.
+func (s *HiTalentServer) parseSearchQuery(w http.ResponseWriter, r *http.Request) (*models.SearchQuery, bool) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		s.writeProblem(w, r, problemInvalidParameter, "limit: "+err.Error())
+		return nil, false
+	}
+
+	query := &models.SearchQuery{
+		Query: r.URL.Query().Get("q"),
+		Limit: limit,
+	}
+
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		cursor, err := models.DecodeSearchCursor(v)
+		if err != nil {
+			s.writeError(w, r, err)
+			return nil, false
+		}
+		query.Cursor = cursor
+	}
+
+	return query, true
+}
+
+func parseLimit(r *http.Request) (int, error) {
+	limitStr := r.URL.Query().Get("limit")
+	limit := 20
+
+	if limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			return 0, err
+		}
+		limit = parsedLimit
+		if limit > 100 {
+			limit = 100
+		}
+		if limit < 1 {
+			limit = 1
+		}
+	}
+
+	return limit, nil
+}
+
+func chatETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch extracts the chat version from an If-Match header. "*" yields
+// zero, which means the update is unconditional. If-Match compares tags
+// strongly (RFC 7232, section 3.1), so a weak tag matches no version.
+func parseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(header, "W/") {
+		return 0, suberrors.ErrChatVersionMismatch
+	}
+
+	tag := header
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, errors.New("entity tag must be a quoted chat version")
+	}
+
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version <= 0 {
+		return 0, errors.New("entity tag must be a quoted chat version")
+	}
+
+	return version, nil
+}
@@ -1,15 +1,20 @@
 package transport
 
 import (
+	"TestHitalent/internal/auth"
 	"TestHitalent/internal/config"
 	"TestHitalent/internal/models"
 	"TestHitalent/internal/service/mocks"
 	"TestHitalent/pkg/suberrors"
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -37,7 +42,7 @@ func TestCreateChatHandler_Success(t *testing.T) {
 		CreatedAt: time.Now(),
 	}
 
-	srv.EXPECT().CreateChat(gomock.Any()).Return(expectedChat, nil).Times(1)
+	srv.EXPECT().CreateChat(gomock.Any(), nil, gomock.Any()).Return(expectedChat, nil).Times(1)
 
 	server := NewHiTalentServer(cfg, srv, ctx)
 
@@ -58,6 +63,47 @@ func TestCreateChatHandler_Success(t *testing.T) {
 	require.Equal(t, expectedChat.Title, response.Title)
 }
 
+func TestCreateChatHandler_WithMessages(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+
+	srv.EXPECT().CreateChat(gomock.Any(), nil, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *models.Principal, chat *models.Chat) (*models.Chat, error) {
+			require.Len(t, chat.Messages, 2)
+			chat.ID = 1
+			for i, message := range chat.Messages {
+				message.ID = i + 1
+				message.ChatID = chat.ID
+			}
+			return chat, nil
+		}).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	body := `{"title": "Onboarding", "messages": [{"text": "Welcome"}, {"text": "Read the rules"}]}`
+	req := httptest.NewRequest("POST", "/api/v1/chats", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+
+	CreateChatHandler(server)(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var response models.Chat
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	require.Len(t, response.Messages, 2)
+	require.Equal(t, 2, response.Messages[1].ID)
+	require.Equal(t, "Read the rules", response.Messages[1].Text)
+}
+
 func TestCreateChatHandler_Fail(t *testing.T) {
 	ctx := context.Background()
 	cfg := &config.Config{
@@ -135,7 +181,7 @@ func TestGetChatHandler_Success(t *testing.T) {
 		},
 	}
 
-	srv.EXPECT().GetChat("1", 20).Return(expectedResponse, nil).Times(1)
+	srv.EXPECT().GetChat(gomock.Any(), nil, "1", &models.MessagesQuery{Limit: 20}).Return(expectedResponse, nil).Times(1)
 
 	server := NewHiTalentServer(cfg, srv, ctx)
 
@@ -156,6 +202,91 @@ func TestGetChatHandler_Success(t *testing.T) {
 	require.Len(t, response.Messages, 2)
 }
 
+func TestGetChatHandler_Cursor(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+
+	before := &models.MessageCursor{CreatedAt: time.Unix(1700000000, 123000).UTC(), ID: 42}
+	expectedResponse := &models.ChatAndMessagesResponse{
+		Chat: &models.Chat{
+			ID:        1,
+			Title:     "Test Chat",
+			CreatedAt: time.Now(),
+		},
+		Messages: []*models.Message{
+			{ID: 41, ChatID: 1, Text: "Message 41", CreatedAt: time.Now()},
+		},
+		NextCursor: "next",
+		HasMore:    true,
+	}
+
+	srv.EXPECT().GetChat(gomock.Any(), nil, "1", &models.MessagesQuery{Limit: 1, Before: before}).Return(expectedResponse, nil).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	req := httptest.NewRequest("GET", "/api/v1/chats/1?limit=1&before="+before.Encode(), nil)
+	req.SetPathValue("id", "1")
+
+	w := httptest.NewRecorder()
+
+	GetChatHandler(server)(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ChatAndMessagesResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	require.NoError(t, err)
+	require.Equal(t, "next", response.NextCursor)
+	require.True(t, response.HasMore)
+	require.Len(t, response.Messages, 1)
+}
+
+func TestGetChatHandler_SenderFilter(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+
+	expectedResponse := &models.ChatAndMessagesResponse{
+		Chat: &models.Chat{ID: 1, Title: "Test Chat", CreatedAt: time.Now()},
+		Messages: []*models.Message{
+			{ID: 3, ChatID: 1, SenderID: "42", SenderName: "Alice", Text: "Hello", CreatedAt: time.Now()},
+		},
+	}
+
+	srv.EXPECT().GetChat(gomock.Any(), nil, "1", &models.MessagesQuery{Limit: 20, SenderID: "42"}).Return(expectedResponse, nil).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	req := httptest.NewRequest("GET", "/api/v1/chats/1?sender_id=42", nil)
+	req.SetPathValue("id", "1")
+
+	w := httptest.NewRecorder()
+
+	GetChatHandler(server)(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ChatAndMessagesResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	require.NoError(t, err)
+	require.Len(t, response.Messages, 1)
+	require.Equal(t, "42", response.Messages[0].SenderID)
+	require.Equal(t, "Alice", response.Messages[0].SenderName)
+}
+
 func TestGetChatHandler_Fail(t *testing.T) {
 	ctx := context.Background()
 	cfg := &config.Config{
@@ -167,6 +298,8 @@ func TestGetChatHandler_Fail(t *testing.T) {
 		name           string
 		chatID         string
 		limit          string
+		before         string
+		after          string
 		expectedStatus int
 		expectedError  string
 	}{
@@ -175,7 +308,29 @@ func TestGetChatHandler_Fail(t *testing.T) {
 			chatID:         "1",
 			limit:          "invalid",
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Invalid limit parameter",
+			expectedError:  "invalid_parameter",
+		},
+		{
+			name:           "malformed before cursor",
+			chatID:         "1",
+			before:         "not-a-cursor",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_cursor",
+		},
+		{
+			name:           "malformed after cursor",
+			chatID:         "1",
+			after:          "MTIz",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_cursor",
+		},
+		{
+			name:           "both cursors",
+			chatID:         "1",
+			before:         (&models.MessageCursor{CreatedAt: time.Now(), ID: 2}).Encode(),
+			after:          (&models.MessageCursor{CreatedAt: time.Now(), ID: 1}).Encode(),
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_parameter",
 		},
 	}
 
@@ -187,11 +342,17 @@ func TestGetChatHandler_Fail(t *testing.T) {
 			srv := mocks.NewMockHiTalentServiceInterface(ctl)
 			server := NewHiTalentServer(cfg, srv, ctx)
 
-			url := "/api/v1/chats/" + tc.chatID
+			params := url.Values{}
 			if tc.limit != "" {
-				url += "?limit=" + tc.limit
+				params.Set("limit", tc.limit)
+			}
+			if tc.before != "" {
+				params.Set("before", tc.before)
 			}
-			req := httptest.NewRequest("GET", url, nil)
+			if tc.after != "" {
+				params.Set("after", tc.after)
+			}
+			req := httptest.NewRequest("GET", "/api/v1/chats/"+tc.chatID+"?"+params.Encode(), nil)
 			req.SetPathValue("id", tc.chatID)
 
 			w := httptest.NewRecorder()
@@ -204,6 +365,131 @@ func TestGetChatHandler_Fail(t *testing.T) {
 	}
 }
 
+func TestListChatsHandler_Success(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+
+	createdFrom := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursor := &models.ChatCursor{Sort: models.ChatSortLastActivity, Order: models.SortOrderAsc, SortValue: time.Unix(1700000000, 0).UTC(), ID: 7}
+	expectedResponse := &models.ChatListResponse{
+		Chats: []*models.Chat{
+			{ID: 6, Title: "Support", CreatedAt: time.Now()},
+		},
+		NextCursor: "next",
+		HasMore:    true,
+	}
+
+	srv.EXPECT().ListChats(gomock.Any(), nil, &models.ChatsQuery{
+		Limit:       1,
+		Title:       "sup",
+		CreatedFrom: &createdFrom,
+		Sort:        models.ChatSortLastActivity,
+		Order:       models.SortOrderAsc,
+		Cursor:      cursor,
+	}).Return(expectedResponse, nil).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	params := url.Values{}
+	params.Set("limit", "1")
+	params.Set("title", "sup")
+	params.Set("created_from", "2026-01-01T00:00:00Z")
+	params.Set("sort", models.ChatSortLastActivity)
+	params.Set("order", models.SortOrderAsc)
+	params.Set("cursor", cursor.Encode())
+	req := httptest.NewRequest("GET", "/api/v1/chats?"+params.Encode(), nil)
+
+	w := httptest.NewRecorder()
+
+	ListChatsHandler(server)(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ChatListResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	require.NoError(t, err)
+	require.Len(t, response.Chats, 1)
+	require.Equal(t, "next", response.NextCursor)
+	require.True(t, response.HasMore)
+}
+
+func TestListChatsHandler_Fail(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+
+	cases := []struct {
+		name           string
+		query          string
+		serviceErr     error
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "invalid limit parameter",
+			query:          "limit=abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_parameter",
+		},
+		{
+			name:           "invalid created_from",
+			query:          "created_from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "created_from",
+		},
+		{
+			name:           "invalid created_to",
+			query:          "created_to=2026-13-01",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "created_to",
+		},
+		{
+			name:           "invalid cursor",
+			query:          "cursor=not-a-cursor",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_cursor",
+		},
+		{
+			name:           "invalid sort",
+			query:          "sort=title",
+			serviceErr:     suberrors.ErrInvalidSort,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_sort",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+			if tc.serviceErr != nil {
+				srv.EXPECT().ListChats(gomock.Any(), nil, gomock.Any()).Return(nil, tc.serviceErr).Times(1)
+			}
+			server := NewHiTalentServer(cfg, srv, ctx)
+
+			req := httptest.NewRequest("GET", "/api/v1/chats?"+tc.query, nil)
+
+			w := httptest.NewRecorder()
+
+			ListChatsHandler(server)(w, req)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			require.Contains(t, w.Body.String(), tc.expectedError)
+		})
+	}
+}
+
 func TestCreateMessageHandler_Success(t *testing.T) {
 	ctx := context.Background()
 	ctl := gomock.NewController(t)
@@ -225,7 +511,7 @@ func TestCreateMessageHandler_Success(t *testing.T) {
 		CreatedAt: time.Now(),
 	}
 
-	srv.EXPECT().CreateMessage("1", gomock.Any()).Return(expectedMessage, nil).Times(1)
+	srv.EXPECT().CreateMessage(gomock.Any(), nil, "1", gomock.Any()).Return(expectedMessage, nil).Times(1)
 
 	server := NewHiTalentServer(cfg, srv, ctx)
 
@@ -317,7 +603,7 @@ func TestDeleteChatHandler_Success(t *testing.T) {
 
 	srv := mocks.NewMockHiTalentServiceInterface(ctl)
 
-	srv.EXPECT().DeleteChat("1").Return(nil).Times(1)
+	srv.EXPECT().DeleteChat(gomock.Any(), nil, "1").Return(nil).Times(1)
 
 	server := NewHiTalentServer(cfg, srv, ctx)
 
@@ -343,7 +629,7 @@ func TestDeleteChatHandler_Fail(t *testing.T) {
 
 	srv := mocks.NewMockHiTalentServiceInterface(ctl)
 
-	srv.EXPECT().DeleteChat("999").Return(suberrors.ErrChatNotFound).Times(1)
+	srv.EXPECT().DeleteChat(gomock.Any(), nil, "999").Return(suberrors.ErrChatNotFound).Times(1)
 
 	server := NewHiTalentServer(cfg, srv, ctx)
 
@@ -357,3 +643,512 @@ func TestDeleteChatHandler_Fail(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, w.Code)
 	require.Contains(t, w.Body.String(), "Chat not found")
 }
+
+func TestDeleteChatHandler_Forbidden(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+
+	principal := &models.Principal{ID: "42", Method: models.AuthMethodJWT}
+	srv.EXPECT().DeleteChat(gomock.Any(), principal, "1").Return(suberrors.ErrForbidden).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	req := httptest.NewRequest("DELETE", "/api/v1/chats/1", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	req.SetPathValue("id", "1")
+
+	w := httptest.NewRecorder()
+
+	DeleteChatHandler(server)(w, req)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Contains(t, w.Body.String(), "Forbidden")
+}
+
+func TestUpdateMessageHandler_Success(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+
+	editedAt := time.Now()
+	expectedMessage := &models.Message{
+		ID:        2,
+		ChatID:    1,
+		Text:      "Edited message",
+		CreatedAt: time.Now(),
+		EditedAt:  &editedAt,
+	}
+
+	srv.EXPECT().UpdateMessage(gomock.Any(), nil, "1", "2", gomock.Any()).Return(expectedMessage, nil).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	req := httptest.NewRequest("PATCH", "/api/v1/chats/1/messages/2", bytes.NewBufferString(`{"text": "Edited message"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", "1")
+	req.SetPathValue("msgId", "2")
+
+	w := httptest.NewRecorder()
+
+	UpdateMessageHandler(server)(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response models.Message
+	err := json.NewDecoder(w.Body).Decode(&response)
+	require.NoError(t, err)
+	require.Equal(t, expectedMessage.Text, response.Text)
+	require.NotNil(t, response.EditedAt)
+}
+
+func TestUpdateMessageHandler_Fail(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+
+	cases := []struct {
+		name           string
+		requestBody    string
+		serviceErr     error
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "invalid JSON",
+			requestBody:    "invalid json",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid request body",
+		},
+		{
+			name:           "message not found",
+			requestBody:    `{"text": "Edited message"}`,
+			serviceErr:     suberrors.ErrMessageNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Message not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+			if tc.serviceErr != nil {
+				srv.EXPECT().UpdateMessage(gomock.Any(), nil, "1", "2", gomock.Any()).Return(nil, tc.serviceErr).Times(1)
+			}
+			server := NewHiTalentServer(cfg, srv, ctx)
+
+			req := httptest.NewRequest("PATCH", "/api/v1/chats/1/messages/2", bytes.NewBufferString(tc.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.SetPathValue("id", "1")
+			req.SetPathValue("msgId", "2")
+
+			w := httptest.NewRecorder()
+
+			UpdateMessageHandler(server)(w, req)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			require.Contains(t, w.Body.String(), tc.expectedError)
+		})
+	}
+}
+
+func TestDeleteMessageHandler_Success(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+
+	srv.EXPECT().DeleteMessage(gomock.Any(), nil, "1", "2").Return(nil).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	req := httptest.NewRequest("DELETE", "/api/v1/chats/1/messages/2", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("msgId", "2")
+
+	w := httptest.NewRecorder()
+
+	DeleteMessageHandler(server)(w, req)
+
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Empty(t, w.Body.String())
+}
+
+func TestDeleteMessageHandler_Fail(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+
+	srv.EXPECT().DeleteMessage(gomock.Any(), nil, "1", "999").Return(suberrors.ErrMessageNotFound).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	req := httptest.NewRequest("DELETE", "/api/v1/chats/1/messages/999", nil)
+	req.SetPathValue("id", "1")
+	req.SetPathValue("msgId", "999")
+
+	w := httptest.NewRecorder()
+
+	DeleteMessageHandler(server)(w, req)
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Contains(t, w.Body.String(), "Message not found")
+}
+
+func TestUpdateChatHandler_Success(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+
+	cases := []struct {
+		name            string
+		ifMatch         string
+		expectedVersion int
+	}{
+		{
+			name:            "wildcard",
+			ifMatch:         "*",
+			expectedVersion: 0,
+		},
+		{
+			name:            "strong etag",
+			ifMatch:         `"2"`,
+			expectedVersion: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+
+			expectedChat := &models.Chat{
+				ID:        1,
+				Title:     "Renamed",
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				Version:   3,
+			}
+			srv.EXPECT().UpdateChat(gomock.Any(), nil, "1", gomock.Any(), tc.expectedVersion).Return(expectedChat, nil).Times(1)
+
+			server := NewHiTalentServer(cfg, srv, ctx)
+
+			req := httptest.NewRequest("PATCH", "/api/v1/chats/1", bytes.NewBufferString(`{"title": "Renamed"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			req.SetPathValue("id", "1")
+
+			w := httptest.NewRecorder()
+
+			UpdateChatHandler(server)(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+			var response models.Chat
+			err := json.NewDecoder(w.Body).Decode(&response)
+			require.NoError(t, err)
+			require.Equal(t, "Renamed", response.Title)
+			require.Equal(t, 3, response.Version)
+		})
+	}
+}
+
+func TestUpdateChatHandler_Fail(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+
+	cases := []struct {
+		name           string
+		ifMatch        string
+		requestBody    string
+		serviceErr     error
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "unquoted If-Match",
+			ifMatch:        "2",
+			requestBody:    `{"title": "Renamed"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_header",
+		},
+		{
+			name:           "non numeric If-Match",
+			ifMatch:        `"abc"`,
+			requestBody:    `{"title": "Renamed"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_header",
+		},
+		{
+			name:           "weak If-Match",
+			ifMatch:        `W/"2"`,
+			requestBody:    `{"title": "Renamed"}`,
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedError:  "chat_version_mismatch",
+		},
+		{
+			name:           "missing If-Match",
+			requestBody:    `{"title": "Renamed"}`,
+			expectedStatus: http.StatusPreconditionRequired,
+			expectedError:  "if_match_required",
+		},
+		{
+			name:           "invalid JSON",
+			ifMatch:        "*",
+			requestBody:    "invalid json",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid request body",
+		},
+		{
+			name:           "chat not found",
+			ifMatch:        "*",
+			requestBody:    `{"title": "Renamed"}`,
+			serviceErr:     suberrors.ErrChatNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Chat not found",
+		},
+		{
+			name:           "stale version",
+			ifMatch:        `"1"`,
+			requestBody:    `{"title": "Renamed"}`,
+			serviceErr:     suberrors.ErrChatVersionMismatch,
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedError:  "Chat was modified",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+			if tc.serviceErr != nil {
+				srv.EXPECT().UpdateChat(gomock.Any(), nil, "1", gomock.Any(), gomock.Any()).Return(nil, tc.serviceErr).Times(1)
+			}
+			server := NewHiTalentServer(cfg, srv, ctx)
+
+			req := httptest.NewRequest("PATCH", "/api/v1/chats/1", bytes.NewBufferString(tc.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			req.SetPathValue("id", "1")
+
+			w := httptest.NewRecorder()
+
+			UpdateChatHandler(server)(w, req)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			require.Contains(t, w.Body.String(), tc.expectedError)
+		})
+	}
+}
+
+func TestSearchHandler_Success(t *testing.T) {
+	ctx := context.Background()
+	ctl := gomock.NewController(t)
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+
+	cursor := &models.SearchCursor{Offset: 10}
+	expectedResponse := &models.SearchResponse{
+		Results: []*models.SearchResult{
+			{Message: &models.Message{ID: 3, ChatID: 1, Text: "Deploy at noon"}, Rank: 0.6, Snippet: "<mark>Deploy</mark> at noon"},
+		},
+		NextCursor: "next",
+		HasMore:    true,
+	}
+
+	srv.EXPECT().Search(gomock.Any(), nil, &models.SearchQuery{Query: "deploy", Limit: 5, Cursor: cursor}).Return(expectedResponse, nil).Times(1)
+	srv.EXPECT().SearchChat(gomock.Any(), nil, "1", &models.SearchQuery{Query: "deploy", Limit: 5, Cursor: cursor}).Return(expectedResponse, nil).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, ctx)
+
+	params := url.Values{}
+	params.Set("q", "deploy")
+	params.Set("limit", "5")
+	params.Set("cursor", cursor.Encode())
+
+	for _, handler := range []http.HandlerFunc{SearchHandler(server), SearchChatMessagesHandler(server)} {
+		req := httptest.NewRequest("GET", "/api/v1/search?"+params.Encode(), nil)
+		req.SetPathValue("id", "1")
+
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response models.SearchResponse
+		err := json.NewDecoder(w.Body).Decode(&response)
+		require.NoError(t, err)
+		require.Len(t, response.Results, 1)
+		require.Equal(t, "<mark>Deploy</mark> at noon", response.Results[0].Snippet)
+		require.Equal(t, "next", response.NextCursor)
+		require.True(t, response.HasMore)
+	}
+}
+
+func TestSearchChatMessagesHandler_Fail(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+
+	cases := []struct {
+		name           string
+		query          string
+		serviceErr     error
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "invalid limit parameter",
+			query:          "q=deploy&limit=abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_parameter",
+		},
+		{
+			name:           "invalid cursor",
+			query:          "q=deploy&cursor=not-a-cursor",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid_cursor",
+		},
+		{
+			name:           "empty query",
+			query:          "q=",
+			serviceErr:     suberrors.ErrEmptySearchQuery,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "empty_search_query",
+		},
+		{
+			name:           "chat not found",
+			query:          "q=deploy",
+			serviceErr:     suberrors.ErrChatNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Chat not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctl := gomock.NewController(t)
+			defer ctl.Finish()
+
+			srv := mocks.NewMockHiTalentServiceInterface(ctl)
+			if tc.serviceErr != nil {
+				srv.EXPECT().SearchChat(gomock.Any(), nil, "1", gomock.Any()).Return(nil, tc.serviceErr).Times(1)
+			}
+			server := NewHiTalentServer(cfg, srv, ctx)
+
+			req := httptest.NewRequest("GET", "/api/v1/chats/1/messages/search?"+tc.query, nil)
+			req.SetPathValue("id", "1")
+
+			w := httptest.NewRecorder()
+
+			SearchChatMessagesHandler(server)(w, req)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			require.Contains(t, w.Body.String(), tc.expectedError)
+		})
+	}
+}
+
+func TestHiTalentServer_Shutdown(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	// Timeouts shorter than the test would cut the stream if it kept them.
+	cfg := &config.Config{
+		Host:         "127.0.0.1",
+		Port:         "0",
+		ReadTimeout:  50 * time.Millisecond,
+		WriteTimeout: 50 * time.Millisecond,
+	}
+
+	stream := make(chan *models.Event, 1)
+	stopped := make(chan struct{})
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Authenticate(gomock.Any(), "htk_key").Return(&models.Principal{ID: "api_key:1", Method: models.AuthMethodAPIKey}, nil).Times(1)
+	srv.EXPECT().Subscribe(gomock.Any(), gomock.Any(), "1", 0).Return(stream, func() { close(stopped) }, nil).Times(1)
+
+	server := NewHiTalentServer(cfg, srv, context.Background())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(l)
+	}()
+
+	req, err := http.NewRequest("GET", "http://"+l.Addr().String()+"/api/v1/chats/1/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-API-Key", "htk_key")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	time.Sleep(150 * time.Millisecond)
+	stream <- &models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: &models.Message{ID: 6, ChatID: 1, Text: "Hello"}}
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "id: 6\n", line)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, server.Shutdown(ctx))
+
+	// The open stream ends instead of holding up the drain.
+	_, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	<-stopped
+	require.NoError(t, <-served)
+}
@@ -0,0 +1,189 @@
+package transport
+
+import (
+	"TestHitalent/internal/auth"
+	"TestHitalent/internal/models"
+	"TestHitalent/pkg/logger"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	wsWriteWait    = 10 * time.Second
+	wsPongWait     = 60 * time.Second
+	wsPingInterval = wsPongWait * 9 / 10
+	wsMaxFrameSize = 16 << 10
+
+	// wsReplyBufferSize bounds the error frames queued for a client that is
+	// not reading; the connection is closed once it is exceeded.
+	wsReplyBufferSize = 16
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// wsErrorFrame reports a rejected inbound frame with the same problem body
+// the HTTP endpoints use.
+type wsErrorFrame struct {
+	Type  string   `json:"type"`
+	Error *Problem `json:"error"`
+}
+
+// ChatWebSocketHandler lets a client send and receive chat messages over one
+// connection. Inbound text frames are decoded like the body of
+// POST /api/v1/chats/{id}/messages; persisted messages reach every connected
+// client, the sender included, as the same events the SSE stream delivers.
+func ChatWebSocketHandler(s *HiTalentServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+
+		lastEventId, err := parseLastEventId(r)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+
+		principal := auth.PrincipalFromContext(r.Context())
+
+		events, stop, err := s.service.Subscribe(r.Context(), principal, id, lastEventId)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		defer stop()
+
+		// Upgrade writes its own response, so headers set by middleware such
+		// as X-Request-ID are passed on explicitly.
+		conn, err := upgrader.Upgrade(w, r, w.Header().Clone())
+		if err != nil {
+			// Upgrade has already replied with an HTTP error.
+			return
+		}
+
+		log := s.log(r)
+		replies := make(chan *wsErrorFrame, wsReplyBufferSize)
+		quit := make(chan struct{})
+		writerDone := make(chan struct{})
+
+		go func() {
+			defer close(writerDone)
+			// Closing the connection unblocks the reader below.
+			defer conn.Close()
+			s.writeWebSocket(conn, log, events, replies, quit)
+		}()
+
+		s.readWebSocket(r.Context(), conn, log, principal, id, s.rateLimitKey(r), replies)
+		close(quit)
+		<-writerDone
+	}
+}
+
+func (s *HiTalentServer) readWebSocket(ctx context.Context, conn *websocket.Conn, log *logger.Logger, principal *models.Principal, chatId, rateLimitKey string, replies chan<- *wsErrorFrame) {
+	conn.SetReadLimit(wsMaxFrameSize)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Warn("websocket read failed", zap.Error(err))
+			}
+			return
+		}
+
+		problem := s.postWebSocketMessage(ctx, log, principal, chatId, rateLimitKey, data)
+		if problem == nil {
+			continue
+		}
+
+		reply := &wsErrorFrame{Type: "error", Error: problem}
+
+		select {
+		case replies <- reply:
+		default:
+			log.Warn("websocket client is not reading, closing connection")
+			return
+		}
+	}
+}
+
+// postWebSocketMessage posts the message of an inbound frame and returns the
+// problem to reply with when it was not posted. Frames are limited like
+// POST /api/v1/chats/{id}/messages and drawn from the same bucket.
+func (s *HiTalentServer) postWebSocketMessage(ctx context.Context, log *logger.Logger, principal *models.Principal, chatId, rateLimitKey string, data []byte) *Problem {
+	if problem := s.rateLimitFrame(ctx, log, rateLimitKey); problem != nil {
+		return problem
+	}
+
+	req := new(models.Message)
+	if err := json.Unmarshal(data, req); err != nil {
+		return newProblem(problemInvalidBody, err.Error())
+	}
+	if _, err := s.service.CreateMessage(ctx, principal, chatId, req); err != nil {
+		problem, known := problemFor(err)
+		if !known {
+			log.Error("websocket message was not sent", zap.Error(err))
+		}
+		return problem
+	}
+	return nil
+}
+
+func (s *HiTalentServer) writeWebSocket(conn *websocket.Conn, log *logger.Logger, events <-chan *models.Event, replies <-chan *wsErrorFrame, quit <-chan struct{}) {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-s.draining:
+			closeWebSocket(conn, websocket.CloseGoingAway, "server shutting down")
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		case reply := <-replies:
+			if err := writeWebSocketJSON(conn, reply); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				// The broker drops subscribers that fall behind; the client
+				// reconnects with last_event_id to catch up from storage.
+				closeWebSocket(conn, websocket.CloseTryAgainLater, "subscriber fell behind")
+				return
+			}
+			if err := writeWebSocketJSON(conn, event); err != nil {
+				log.Warn("failed to write event", zap.Error(err))
+				return
+			}
+			if event.Type == models.EventChatDeleted {
+				closeWebSocket(conn, websocket.CloseNormalClosure, "chat deleted")
+				return
+			}
+		}
+	}
+}
+
+func writeWebSocketJSON(conn *websocket.Conn, v any) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(wsWriteWait)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(v)
+}
+
+func closeWebSocket(conn *websocket.Conn, code int, reason string) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteWait))
+}
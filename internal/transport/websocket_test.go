@@ -0,0 +1,130 @@
+package transport
+
+import (
+	"TestHitalent/internal/config"
+	"TestHitalent/internal/models"
+	"TestHitalent/internal/ratelimit"
+	"TestHitalent/internal/service/mocks"
+	"TestHitalent/pkg/suberrors"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newWebSocketTestServer(t *testing.T, srv *mocks.MockHiTalentServiceInterface, opts ...Option) *httptest.Server {
+	cfg := &config.Config{
+		Host: "localhost",
+		Port: "4047",
+	}
+	server := NewHiTalentServer(cfg, srv, context.Background(), opts...)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/chats/{id}/ws", ChatWebSocketHandler(server))
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestChatWebSocketHandler_Success(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+
+	stream := make(chan *models.Event, 1)
+	stopped := make(chan struct{})
+	srv.EXPECT().Subscribe(gomock.Any(), nil, "1", 0).Return(stream, func() { close(stopped) }, nil).Times(1)
+
+	created := &models.Message{ID: 6, ChatID: 1, Text: "Hello", CreatedAt: time.Now().UTC()}
+	srv.EXPECT().CreateMessage(gomock.Any(), nil, "1", &models.Message{Text: "Hello"}).DoAndReturn(func(context.Context, *models.Principal, string, *models.Message) (*models.Message, error) {
+		stream <- &models.Event{Type: models.EventMessageCreated, ChatID: 1, Message: created}
+		return created, nil
+	}).Times(1)
+	srv.EXPECT().CreateMessage(gomock.Any(), nil, "1", &models.Message{Text: ""}).Return(nil, suberrors.ErrChatNotFound).Times(1)
+
+	ts := newWebSocketTestServer(t, srv)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/v1/chats/1/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"text":"Hello"}`)))
+
+	var event models.Event
+	require.NoError(t, conn.ReadJSON(&event))
+	require.Equal(t, models.EventMessageCreated, event.Type)
+	require.Equal(t, 6, event.Message.ID)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`not json`)))
+
+	var reply wsErrorFrame
+	require.NoError(t, conn.ReadJSON(&reply))
+	require.Equal(t, "invalid_request_body", reply.Error.Code)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{}`)))
+
+	require.NoError(t, conn.ReadJSON(&reply))
+	require.Equal(t, "chat_not_found", reply.Error.Code)
+
+	stream <- &models.Event{Type: models.EventChatDeleted, ChatID: 1}
+
+	require.NoError(t, conn.ReadJSON(&event))
+	require.Equal(t, models.EventChatDeleted, event.Type)
+
+	_, _, err = conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("subscription was not stopped")
+	}
+}
+
+func TestChatWebSocketHandler_RateLimit(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Subscribe(gomock.Any(), nil, "1", 0).Return(make(chan *models.Event), func() {}, nil).Times(1)
+	srv.EXPECT().CreateMessage(gomock.Any(), nil, "1", &models.Message{Text: "Hello"}).Return(&models.Message{ID: 1, ChatID: 1, Text: "Hello"}, nil).Times(1)
+
+	limiter := ratelimit.NewMemory(0.001, 1)
+	ts := newWebSocketTestServer(t, srv, WithRateLimit(limiter, ratelimit.Config{KeyBy: ratelimit.KeyByPrincipal}))
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/v1/chats/1/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// The first frame takes the only token, the second is refused unread.
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"text":"Hello"}`)))
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"text":"Hello"}`)))
+
+	var reply wsErrorFrame
+	require.NoError(t, conn.ReadJSON(&reply))
+	require.Equal(t, "error", reply.Type)
+	require.Equal(t, "rate_limited", reply.Error.Code)
+	require.Equal(t, http.StatusTooManyRequests, reply.Error.Status)
+}
+
+func TestChatWebSocketHandler_Fail(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+
+	srv := mocks.NewMockHiTalentServiceInterface(ctl)
+	srv.EXPECT().Subscribe(gomock.Any(), nil, "404", 0).Return(nil, nil, suberrors.ErrChatNotFound).Times(1)
+
+	ts := newWebSocketTestServer(t, srv)
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/v1/chats/404/ws", nil)
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE messages
+    ADD COLUMN edited_at TIMESTAMP,
+    ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE TABLE message_revisions (
+                                   id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+                                   message_id INT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+                                   text TEXT NOT NULL,
+                                   created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_message_revisions_message_id ON message_revisions(message_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS message_revisions;
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS edited_at;
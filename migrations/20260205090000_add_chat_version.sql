@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE chats
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+UPDATE chats SET updated_at = created_at WHERE created_at IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE chats
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE api_keys (
+                          id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+                          name VARCHAR(255) NOT NULL,
+                          key_hash CHAR(64) NOT NULL UNIQUE,
+                          created_at TIMESTAMP NOT NULL DEFAULT now(),
+                          revoked_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_api_keys_active_name ON api_keys(name) WHERE revoked_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS api_keys;
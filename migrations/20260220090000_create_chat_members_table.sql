@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE chat_members (
+                              chat_id INT NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+                              user_id VARCHAR(255) NOT NULL,
+                              role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'member', 'read_only')),
+                              created_at TIMESTAMP NOT NULL DEFAULT now(),
+                              PRIMARY KEY (chat_id, user_id)
+);
+
+CREATE INDEX idx_chat_members_user_id ON chat_members(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS chat_members;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE messages
+    ADD COLUMN sender_id VARCHAR(255),
+    ADD COLUMN sender_name VARCHAR(255);
+
+CREATE INDEX idx_messages_chat_id_sender_id_created_at ON messages(chat_id, sender_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_messages_chat_id_sender_id_created_at;
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS sender_name,
+    DROP COLUMN IF EXISTS sender_id;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE idempotency_keys
+    ADD COLUMN claim_token CHAR(32) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE idempotency_keys
+    DROP COLUMN IF EXISTS claim_token;
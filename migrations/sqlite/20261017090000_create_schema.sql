@@ -0,0 +1,87 @@
+-- +goose Up
+CREATE TABLE chats (
+                       id INTEGER PRIMARY KEY AUTOINCREMENT,
+                       title VARCHAR(255) NOT NULL,
+                       created_at DATETIME NOT NULL,
+                       updated_at DATETIME NOT NULL,
+                       version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE messages (
+                          id INTEGER PRIMARY KEY AUTOINCREMENT,
+                          chat_id INTEGER NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+                          sender_id VARCHAR(255),
+                          sender_name VARCHAR(255),
+                          text TEXT NOT NULL,
+                          created_at DATETIME NOT NULL,
+                          edited_at DATETIME,
+                          deleted_at DATETIME
+);
+
+CREATE INDEX idx_messages_chat_id_created_at ON messages(chat_id, created_at DESC);
+CREATE INDEX idx_messages_chat_id_sender_id_created_at ON messages(chat_id, sender_id, created_at DESC);
+
+CREATE TABLE message_revisions (
+                                   id INTEGER PRIMARY KEY AUTOINCREMENT,
+                                   message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+                                   text TEXT NOT NULL,
+                                   created_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_message_revisions_message_id ON message_revisions(message_id);
+
+CREATE TABLE api_keys (
+                          id INTEGER PRIMARY KEY AUTOINCREMENT,
+                          name VARCHAR(255) NOT NULL,
+                          key_hash CHAR(64) NOT NULL UNIQUE,
+                          created_at DATETIME NOT NULL,
+                          revoked_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_api_keys_active_name ON api_keys(name) WHERE revoked_at IS NULL;
+
+CREATE TABLE chat_members (
+                              chat_id INTEGER NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+                              user_id VARCHAR(255) NOT NULL,
+                              role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'member', 'read_only')),
+                              created_at DATETIME NOT NULL,
+                              PRIMARY KEY (chat_id, user_id)
+);
+
+CREATE INDEX idx_chat_members_user_id ON chat_members(user_id);
+
+-- The full-text index reads message text from the messages table and is
+-- kept in sync by triggers.
+CREATE VIRTUAL TABLE messages_fts USING fts5(
+    text,
+    content = 'messages',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 0'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER messages_fts_insert AFTER INSERT ON messages BEGIN
+    INSERT INTO messages_fts (rowid, text) VALUES (new.id, new.text);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER messages_fts_delete AFTER DELETE ON messages BEGIN
+    INSERT INTO messages_fts (messages_fts, rowid, text) VALUES ('delete', old.id, old.text);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER messages_fts_update AFTER UPDATE OF text ON messages BEGIN
+    INSERT INTO messages_fts (messages_fts, rowid, text) VALUES ('delete', old.id, old.text);
+    INSERT INTO messages_fts (rowid, text) VALUES (new.id, new.text);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS messages_fts;
+DROP TABLE IF EXISTS chat_members;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS message_revisions;
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS chats;
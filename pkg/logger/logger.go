@@ -34,6 +34,11 @@ func (l Logger) Fatal(msg string, fields ...zap.Field) {
 	l.l.Fatal(msg, fields...)
 }
 
+// With returns a logger that adds fields to every entry.
+func (l Logger) With(fields ...zap.Field) *Logger {
+	return &Logger{l: l.l.With(fields...)}
+}
+
 func New(ctx context.Context) (context.Context, error) {
 	logger, err := zap.NewProduction()
 	if err != nil {
@@ -43,6 +48,16 @@ func New(ctx context.Context) (context.Context, error) {
 	return ctx, nil
 }
 
+// WithLogger stores a logger in the context, e.g. one scoped to a request.
+func WithLogger(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, Key, l)
+}
+
+// GetLoggerFromCtx returns the logger stored in the context. A context
+// without one, as in tests, gets a logger that discards everything.
 func GetLoggerFromCtx(ctx context.Context) *Logger {
-	return ctx.Value(Key).(*Logger)
+	if l, ok := ctx.Value(Key).(*Logger); ok {
+		return l
+	}
+	return &Logger{l: zap.NewNop()}
 }
@@ -1,33 +1,57 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// Drivers the repository can serve hot paths with.
+const (
+	DriverPgx  = "pgx"
+	DriverGORM = "gorm"
+)
+
 type Config struct {
 	Host     string `yaml:"postgres_host" env:"POSTGRES_HOST" env-default:"localhost"`
 	Port     string `yaml:"postgres_port" env:"POSTGRES_PORT" env-default:"5434"`
 	Database string `yaml:"postgres_db" env:"POSTGRES_DB" env-default:"postgres"`
 	User     string `yaml:"postgres_user" env:"POSTGRES_USER" env-default:"root"`
 	Password string `yaml:"postgres_password" env:"POSTGRES_PASSWORD" env-default:"1234"`
+	// LogLevel is the least severe GORM message logged: silent, error, warn
+	// or info. Info logs every statement.
+	LogLevel string `yaml:"postgres_log_level" env:"POSTGRES_LOG_LEVEL" env-default:"warn"`
+	// PoolMaxConns caps the pgx pool, which is separate from GORM's.
+	PoolMaxConns int32 `yaml:"postgres_pool_max_conns" env:"POSTGRES_POOL_MAX_CONNS" env-default:"20"`
+	// Driver serves reading chats and posting messages with pgx prepared
+	// statements, or with GORM like every other query.
+	Driver string `yaml:"postgres_driver" env:"POSTGRES_DRIVER" env-default:"pgx"`
 }
 
-func New(config Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
-		config.Host,
-		config.User,
-		config.Password,
-		config.Database,
-		config.Port,
+func (c Config) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
+		c.Host,
+		c.User,
+		c.Password,
+		c.Database,
+		c.Port,
 	)
+}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+func New(config Config) (*gorm.DB, error) {
+	logLevel, err := parseLogLevel(config.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(postgres.Open(config.DSN()), &gorm.Config{
+		Logger: logger.Default.LogMode(logLevel),
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
@@ -47,3 +71,40 @@ func New(config Config) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// NewPool opens a pgx connection pool. afterConnect, when set, runs on every
+// new connection, for example to prepare statements.
+func NewPool(ctx context.Context, config Config, tracer pgx.QueryTracer, afterConnect func(context.Context, *pgx.Conn) error) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(config.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse database config: %w", err)
+	}
+
+	if config.PoolMaxConns > 0 {
+		poolConfig.MaxConns = config.PoolMaxConns
+	}
+	poolConfig.MaxConnLifetime = time.Hour
+	poolConfig.ConnConfig.Tracer = tracer
+	poolConfig.AfterConnect = afterConnect
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
+	}
+
+	return pool, nil
+}
+
+func parseLogLevel(level string) (logger.LogLevel, error) {
+	switch level {
+	case "silent":
+		return logger.Silent, nil
+	case "error":
+		return logger.Error, nil
+	case "warn", "":
+		return logger.Warn, nil
+	case "info":
+		return logger.Info, nil
+	}
+	return 0, fmt.Errorf("postgres log level must be silent, error, warn or info, got %q", level)
+}
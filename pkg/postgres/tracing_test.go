@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type chat struct {
+	ID    int
+	Title string
+}
+
+func TestTracingPlugin(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	// A dry run builds statements without a server to send them to.
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: Config{Host: "localhost", Port: "5432"}.DSN()}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(NewTracingPlugin(tp)))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	var chats []chat
+	require.NoError(t, db.WithContext(ctx).Where("title = ?", "Support").Find(&chats).Error)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	require.Equal(t, "gorm.query chats", span.Name)
+	require.Equal(t, trace.SpanKindClient, span.SpanKind)
+	require.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+	require.Contains(t, span.Attributes, attribute.String("db.query.text", `SELECT * FROM "chats" WHERE title = $1`))
+}
+
+func TestQueryTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := NewQueryTracer(tp)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "get_chat"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{Err: pgx.ErrNoRows})
+	queryCtx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "create_message"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{Err: errors.New("connection reset")})
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	found := spans[0]
+	require.Equal(t, "pgx.query", found.Name)
+	require.Equal(t, trace.SpanKindClient, found.SpanKind)
+	require.Equal(t, parent.SpanContext().SpanID(), found.Parent.SpanID())
+	require.Contains(t, found.Attributes, attribute.String("db.query.text", "get_chat"))
+	// A missing row is an answer, not a failed query.
+	require.Equal(t, codes.Unset, found.Status.Code)
+
+	failed := spans[1]
+	require.Contains(t, failed.Attributes, attribute.String("db.query.text", "create_message"))
+	require.Equal(t, codes.Error, failed.Status.Code)
+}
@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type Config struct {
+	Path string `yaml:"sqlite_path" env:"SQLITE_PATH" env-default:"hitalent.db"`
+}
+
+// DSN enables foreign keys, which SQLite leaves off by default, and stores
+// timestamps in a format that sorts as text in time order.
+func (c Config) DSN() string {
+	return fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_time_format=sqlite", c.Path)
+}
+
+func New(config Config) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(config.DSN()), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+		NowFunc: func() time.Time {
+			return time.Now().UTC()
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get database instance: %w", err)
+	}
+
+	// SQLite allows one writer at a time. A single connection queues writes
+	// in the pool instead of failing them with SQLITE_BUSY.
+	sqlDB.SetMaxOpenConns(1)
+
+	return db, nil
+}
@@ -3,7 +3,27 @@ package suberrors
 import "errors"
 
 var (
-	ErrInvalidChatId     = errors.New("invalid chat id format")
-	ErrNotPositiveChatId = errors.New("chat id must be positive")
-	ErrChatNotFound      = errors.New("chat id not found")
+	ErrInvalidChatId        = errors.New("invalid chat id format")
+	ErrNotPositiveChatId    = errors.New("chat id must be positive")
+	ErrChatNotFound         = errors.New("chat id not found")
+	ErrChatVersionMismatch  = errors.New("chat version mismatch")
+	ErrInvalidChatVersion   = errors.New("chat version must be positive")
+	ErrInvalidMessageId     = errors.New("invalid message id format")
+	ErrNotPositiveMessageId = errors.New("message id must be positive")
+	ErrMessageNotFound      = errors.New("message id not found")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrInvalidEventId       = errors.New("invalid event id")
+	ErrInvalidSort          = errors.New("invalid sort field")
+	ErrInvalidSortOrder     = errors.New("invalid sort order")
+	ErrInvalidTimeRange     = errors.New("invalid time range")
+	ErrEmptySearchQuery     = errors.New("search query is required")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrMemberNotFound       = errors.New("chat member not found")
+	ErrLastOwner            = errors.New("chat must keep at least one owner")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrEmptyMessageBatch    = errors.New("message batch is empty")
+	ErrMessageBatchTooLarge = errors.New("message batch is too large")
+	ErrMessageRequired      = errors.New("message is required")
+	ErrMessageFromFuture    = errors.New("message created_at is in the future")
 )
@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName names the tracers the service creates.
+const InstrumentationName = "TestHitalent"
+
+// Config selects where spans are exported. Without an endpoint spans are
+// still created, so trace ids reach logs and downstream services, but they
+// are not sent anywhere.
+type Config struct {
+	Endpoint    string  `yaml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName string  `yaml:"service_name" env:"OTEL_SERVICE_NAME" env-default:"chat_service"`
+	SampleRatio float64 `yaml:"trace_sample_ratio" env:"OTEL_TRACES_SAMPLE_RATIO" env-default:"1"`
+}
+
+// New builds a tracer provider exporting over OTLP/HTTP. The caller must shut
+// it down to flush buffered spans.
+func New(ctx context.Context, config Config) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("unable to build trace resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	}
+
+	if config.Endpoint != "" {
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(config.Endpoint))
+		if err != nil {
+			return nil, fmt.Errorf("unable to create OTLP exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+// Tracer returns the service tracer of tp.
+func Tracer(tp trace.TracerProvider) trace.Tracer {
+	return tp.Tracer(InstrumentationName)
+}